@@ -1,8 +1,9 @@
-package main
+package pubsub
 
 import (
 	"ergo.services/ergo/act"
 	"ergo.services/ergo/gen"
+	"harness"
 )
 
 func factory_consumer() gen.ProcessBehavior {
@@ -12,13 +13,22 @@ func factory_consumer() gen.ProcessBehavior {
 type consumer struct {
 	act.Actor
 
-	event gen.Event
+	events     []gen.Event // of all the producers
+	subscribed int         // number of the events subscribed to so far
+	rc         *harness.RunContext
+	stream     *stream
+	node       int  // index of the consumer node
+	sampled    bool // records the delivery latency
 }
 
 type doSubscribe struct{}
 
 func (c *consumer) Init(args ...any) error {
-	c.event = args[0].(gen.Event)
+	c.events = args[0].([]gen.Event)
+	c.rc = args[1].(*harness.RunContext)
+	c.stream = args[2].(*stream)
+	c.node = args[3].(int)
+	c.sampled = args[4].(bool)
 	c.Send(c.PID(), doSubscribe{})
 	return nil
 }
@@ -26,23 +36,26 @@ func (c *consumer) Init(args ...any) error {
 func (c *consumer) HandleMessage(from gen.PID, message any) error {
 	switch message.(type) {
 	case doSubscribe:
-		if _, err := c.MonitorEvent(c.event); err != nil {
-			if err == gen.ErrTimeout {
-				// Retry on timeout
-				c.Send(c.PID(), doSubscribe{})
-				return nil
+		for c.subscribed < len(c.events) {
+			if _, err := c.MonitorEvent(c.events[c.subscribed]); err != nil {
+				if err == gen.ErrTimeout {
+					// Retry on timeout
+					c.Send(c.PID(), doSubscribe{})
+					return nil
+				}
+				return err
 			}
-			return err
+			c.subscribed++
 		}
-		WGready.Done()
+		c.rc.Ready()
 	}
 	return nil
 }
 
 func (c *consumer) HandleEvent(message gen.MessageEvent) error {
-	switch message.Message.(type) {
+	switch m := message.Message.(type) {
 	case eventMessage:
-		WGreceive.Done()
+		c.stream.receive(c.rc, c.node, c.sampled, m)
 	}
 	return nil
 }
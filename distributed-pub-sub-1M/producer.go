@@ -1,8 +1,11 @@
-package main
+package pubsub
 
 import (
+	"time"
+
 	"ergo.services/ergo/act"
 	"ergo.services/ergo/gen"
+	"harness"
 )
 
 func factory_producer() gen.ProcessBehavior {
@@ -14,12 +17,27 @@ type producer struct {
 
 	token     gen.Ref
 	eventName gen.Atom
+	index     int // the events are numbered from index*events
+	rc        *harness.RunContext
+	stream    *stream
+
+	published int
+
+	// rate mode
+	base     time.Time
+	interval time.Duration
 }
 
 type doRegister struct{}
 
+// doPublish makes the producer publish the events that are due
+type doPublish struct{}
+
 func (p *producer) Init(args ...any) error {
 	p.eventName = args[0].(gen.Atom)
+	p.rc = args[1].(*harness.RunContext)
+	p.stream = args[2].(*stream)
+	p.index = args[3].(int)
 	p.Send(p.PID(), doRegister{})
 	return nil
 }
@@ -27,21 +45,54 @@ func (p *producer) Init(args ...any) error {
 func (p *producer) HandleMessage(from gen.PID, message any) error {
 	switch message.(type) {
 	case doRegister:
-		token, err := p.RegisterEvent(p.eventName, gen.EventOptions{})
+		token, err := p.RegisterEvent(p.eventName, p.stream.options)
 		if err != nil {
 			return err
 		}
 		p.token = token
 		p.Log().Info("Producer registered event '%s'", p.eventName)
-		WGready.Done()
+		p.rc.Ready()
 
 	case startPublish:
-		p.Log().Info("Producer publishing event...")
-		if err := p.SendEvent(p.eventName, p.token, eventMessage{Payload: "test"}); err != nil {
+		if p.stream.events == 1 {
+			p.Log().Info("Producer publishing event...")
+		} else {
+			p.Log().Info("Producer publishing %d events...", p.stream.events)
+		}
+		p.base = time.Now()
+		if p.stream.rate > 0 {
+			p.interval = time.Second / time.Duration(p.stream.rate)
+		}
+		return p.publishDue()
+
+	case doPublish:
+		return p.publishDue()
+	}
+	return nil
+}
+
+// publishDue publishes the events whose time has come (all of them if there
+// is no rate) and schedules the next ones.
+func (p *producer) publishDue() error {
+	s := p.stream
+	for ; p.published < s.events; p.published++ {
+		sent := time.Now()
+		if p.interval > 0 {
+			// the latency is counted from the scheduled time, so falling
+			// behind the schedule isn't hidden
+			sent = p.base.Add(time.Duration(p.published) * p.interval)
+			if wait := time.Until(sent); wait > 0 {
+				p.SendAfter(p.PID(), doPublish{}, wait)
+				return nil
+			}
+		}
+		message := eventMessage{Payload: s.payload, Seq: p.index*s.events + p.published, Sent: sent.UnixNano()}
+		if err := p.SendEvent(p.eventName, p.token, message); err != nil {
 			p.Log().Error("Failed to publish event: %v", err)
 			return err
 		}
-		WGpublish.Done()
+		s.published.Add(1)
 	}
+	s.done(p.rc)
 	return nil
 }
@@ -0,0 +1,152 @@
+package pubsub
+
+import (
+	"fmt"
+	"time"
+
+	"ergo.services/ergo/gen"
+	"ergo.services/ergo/net/edf"
+	"harness"
+)
+
+type startPublish struct{}
+
+// eventMessage is published by the producer. Seq is the number of the event
+// in the stream, Sent is the time it was published at (UnixNano).
+type eventMessage struct {
+	Payload string
+	Seq     int
+	Sent    int64
+}
+
+func init() {
+	// Register types for network transmission
+	if err := edf.RegisterTypeOf(eventMessage{}); err != nil {
+		panic(err)
+	}
+
+	harness.Register(harness.Scenario{
+		Name:        "pubsub/1M",
+		Description: "events (1 by default) are delivered to 1M subscribers on 10 nodes",
+		Params:      pubsubParams(10, 100_000),
+		Run:         runPubSub,
+	})
+	harness.Register(harness.Scenario{
+		Name:        "pubsub/small",
+		Description: "events (1 by default) are delivered to 30 subscribers on 3 nodes (quick test)",
+		Params:      pubsubParams(3, 10),
+		Run:         runPubSub,
+	})
+}
+
+// pubsubParams returns the parameters of a pub/sub scenario with the given
+// topology by default.
+func pubsubParams(nodes int, subscribers int) []harness.Param {
+	return []harness.Param{
+		{Name: "nodes", Usage: "number of consumer nodes", Default: nodes},
+		{Name: "subscribers", Usage: "number of subscribers on every consumer node", Default: subscribers},
+		{Name: "producers", Usage: "number of producers, every subscriber subscribes to the events of all of them", Default: 1},
+		{Name: "events", Usage: "number of events published by every producer", Default: 1},
+		{Name: "rate", Usage: "events per second of every producer, 0 publishes them as fast as possible", Default: 0},
+		{Name: "payload", Usage: "size of the event payload in bytes", Default: 4},
+		{Name: "notify", Usage: "the producers are notified about the first and the last subscriber (gen.EventOptions.Notify)", Default: false},
+		{Name: "buffer", Usage: "number of the last events kept for the new subscribers (gen.EventOptions.Buffer)", Default: 0},
+	}
+}
+
+var (
+	EVENT_NAME gen.Atom = "benchmark.event"
+)
+
+// markPublished is the time the producers have published all the events
+const markPublished = "published"
+
+// runPubSub starts the producer node and the consumer nodes, subscribes the
+// consumers on every node to the events of the producers, makes the producers
+// publish the events and waits until all of them are delivered.
+func runPubSub(b *harness.B) error {
+	s, err := newStream(b)
+	if err != nil {
+		return err
+	}
+	totalSubscribers := s.total()
+
+	fmt.Printf("Step 1: Starting producer node...\n")
+	producerNode, err := b.StartNode("producer@localhost", gen.NodeOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Start consumer nodes
+	fmt.Printf("Step 2: Starting %d consumer nodes...\n", s.nodes)
+	consumerNodes := make([]gen.Node, s.nodes)
+	for i := 0; i < s.nodes; i++ {
+		nodeName := fmt.Sprintf("consumer%d@localhost", i+1)
+		node, err := b.StartNode(gen.Atom(nodeName), gen.NodeOptions{})
+		if err != nil {
+			return err
+		}
+		consumerNodes[i] = node
+	}
+
+	// Connect all consumer nodes to producer node
+	fmt.Printf("Step 3: Connecting nodes...\n")
+	for i := 0; i < s.nodes; i++ {
+		if _, err := consumerNodes[i].Network().GetNode(producerNode.Name()); err != nil {
+			return err
+		}
+		producerNode.Log().Info("Connected to %s", consumerNodes[i].Name())
+	}
+
+	// Spawn producer processes
+	fmt.Printf("Step 4: Starting %d producer process(es)...\n", s.producers)
+	rc := harness.NewRunContext()
+	rc.Expect(s.producers)
+	producers := make([]gen.PID, s.producers)
+	events := make([]gen.Event, s.producers)
+	for i := range producers {
+		name := EVENT_NAME
+		if s.producers > 1 {
+			name = gen.Atom(fmt.Sprintf("%s%d", EVENT_NAME, i+1))
+		}
+		producers[i], err = producerNode.Spawn(factory_producer, gen.ProcessOptions{}, name, rc, s, i)
+		if err != nil {
+			return err
+		}
+		events[i] = gen.Event{
+			Node: producerNode.Name(),
+			Name: name,
+		}
+	}
+	rc.WaitReady() // Wait for producers to register events
+	producerNode.Log().Info("Producer processes started: %v", producers)
+
+	// Spawn consumers on each node
+	fmt.Printf("Step 5: Spawning %d consumers (%d per node)...\n", totalSubscribers, s.subscribers)
+	startSpawn := time.Now()
+	for i := 0; i < s.nodes; i++ {
+		rc.Expect(s.subscribers)
+		for j := 0; j < s.subscribers; j++ {
+			sampled := (i*s.subscribers+j)%sampleEvery == 0
+			_, err := consumerNodes[i].Spawn(factory_consumer, gen.ProcessOptions{}, events, rc, s, i, sampled)
+			if err != nil {
+				return err
+			}
+		}
+		consumerNodes[i].Log().Info("Spawned %d consumers on node %d", s.subscribers, i+1)
+	}
+
+	fmt.Printf("Step 6: Waiting for all consumers to subscribe...\n")
+	rc.WaitReady() // Wait for all consumers to subscribe
+	spawnDuration := time.Since(startSpawn)
+	producerNode.Log().Info("All %d consumers subscribed in %s", totalSubscribers, spawnDuration)
+
+	// Prepare for benchmark
+	fmt.Printf("\n")
+	fmt.Printf("=================================================================\n")
+	fmt.Printf("BENCHMARK START: Publishing %d event(s) to %d subscribers\n", s.producers*s.events, totalSubscribers)
+	fmt.Printf("=================================================================\n")
+
+	b.ReportDuration("subscribe", spawnDuration)
+	return runStream(b, s, rc, producerNode, producers)
+}
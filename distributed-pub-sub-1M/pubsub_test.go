@@ -0,0 +1,46 @@
+package pubsub
+
+import (
+	"fmt"
+	"testing"
+
+	"harness"
+)
+
+func TestPubSubTopology(t *testing.T) {
+	for _, s := range []struct {
+		nodes       int
+		subscribers int
+		producers   int
+	}{
+		{1, 300, 1},
+		{2, 50, 3},
+		{4, 25, 2},
+	} {
+		t.Run(fmt.Sprintf("%dx%d/%d", s.nodes, s.subscribers, s.producers), func(t *testing.T) {
+			r, err := harness.RunOnce("pubsub/small", harness.Params{
+				"nodes":       s.nodes,
+				"subscribers": s.subscribers,
+				"producers":   s.producers,
+				"events":      2,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// every subscriber receives the events of all the producers
+			if expected := s.nodes * s.subscribers * s.producers * 2; r.Ops != expected {
+				t.Fatalf("expected %d deliveries, got %d", expected, r.Ops)
+			}
+		})
+	}
+}
+
+func TestPubSubOptions(t *testing.T) {
+	r, err := harness.RunOnce("pubsub/small", harness.Params{"events": 100, "notify": true, "buffer": 10, "payload": 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 30*100 {
+		t.Fatalf("expected %d deliveries, got %d", 30*100, r.Ops)
+	}
+}
@@ -0,0 +1,192 @@
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+// sampleEvery is the share of the subscribers recording the delivery latency
+// of every event (1 of sampleEvery), so 1M subscribers don't fight over the
+// histogram.
+const sampleEvery = 1000
+
+// backlogInterval is the interval of the backlog samples
+const backlogInterval = 10 * time.Millisecond
+
+// stream keeps track of the delivery of the published events. It's shared by
+// the producers and the consumers (all the nodes run in the same OS process).
+type stream struct {
+	events      int // by every producer
+	rate        int
+	producers   int
+	subscribers int // on every node
+	nodes       int
+	payload     string
+	options     gen.EventOptions
+
+	published atomic.Int64   // by all the producers
+	finished  atomic.Int64   // producers done publishing
+	delivered []atomic.Int64 // per event, by all the subscribers
+	received  []atomic.Int64 // per consumer node, all the events
+	backlog   atomic.Int64   // undelivered events once the producers are done
+	// closed once the producers are done, all the events may be delivered
+	// before that
+	publishDone chan struct{}
+
+	sync.Mutex
+	eventLatency    *harness.Histogram // publish to the delivery to all the subscribers
+	deliveryLatency *harness.Histogram // publish to the delivery to a subscriber (sampled)
+}
+
+func newStream(b *harness.B) (*stream, error) {
+	s := &stream{
+		events:          b.Int("events"),
+		rate:            b.Int("rate"),
+		producers:       b.Int("producers"),
+		subscribers:     b.Int("subscribers"),
+		nodes:           b.Int("nodes"),
+		eventLatency:    harness.NewHistogram(),
+		deliveryLatency: harness.NewHistogram(),
+		publishDone:     make(chan struct{}),
+	}
+	if s.nodes < 1 {
+		return nil, fmt.Errorf("number of consumer nodes must be positive")
+	}
+	if s.subscribers < 1 {
+		return nil, fmt.Errorf("number of subscribers must be positive")
+	}
+	if s.producers < 1 {
+		return nil, fmt.Errorf("number of producers must be positive")
+	}
+	size := b.Int("payload")
+	if size < 0 {
+		return nil, fmt.Errorf("payload size must not be negative")
+	}
+	s.payload = strings.Repeat("x", size)
+	s.options = gen.EventOptions{Notify: b.Bool("notify"), Buffer: b.Int("buffer")}
+	if s.options.Buffer < 0 {
+		return nil, fmt.Errorf("buffer must not be negative")
+	}
+	if s.events < 1 {
+		return nil, fmt.Errorf("number of events must be positive")
+	}
+	if s.rate < 0 {
+		return nil, fmt.Errorf("rate must not be negative")
+	}
+	s.delivered = make([]atomic.Int64, s.producers*s.events)
+	s.received = make([]atomic.Int64, s.nodes)
+	return s, nil
+}
+
+// total is the number of the subscribers on all the nodes.
+func (s *stream) total() int {
+	return s.nodes * s.subscribers
+}
+
+// receive is called by the subscriber on the node once it receives the event.
+// The subscriber that completes the delivery of the event marks it done.
+func (s *stream) receive(rc *harness.RunContext, node int, sampled bool, m eventMessage) {
+	latency := time.Duration(time.Now().UnixNano() - m.Sent)
+	if sampled {
+		s.Lock()
+		s.deliveryLatency.Record(latency)
+		s.Unlock()
+	}
+	s.received[node].Add(1)
+	if s.delivered[m.Seq].Add(1) < int64(s.total()) {
+		return
+	}
+	s.Lock()
+	s.eventLatency.Record(latency)
+	s.Unlock()
+	rc.Done()
+}
+
+// maxBacklog returns the largest number of the events published but not yet
+// received by all the subscribers of a node.
+func (s *stream) maxBacklog() int64 {
+	published := s.published.Load()
+	var backlog int64
+	for i := range s.received {
+		b := published - s.received[i].Load()/int64(s.subscribers)
+		backlog = max(backlog, b)
+	}
+	return backlog
+}
+
+// done is called by the producer once it has published all the events.
+func (s *stream) done(rc *harness.RunContext) {
+	if s.finished.Add(1) < int64(s.producers) {
+		return
+	}
+	s.backlog.Store(s.maxBacklog())
+	rc.Mark(markPublished)
+	close(s.publishDone)
+}
+
+// runStream makes the producers publish the events and waits until all of them
+// are delivered to all the subscribers.
+func runStream(b *harness.B, s *stream, rc *harness.RunContext, producerNode gen.Node, producers []gen.PID) error {
+	events := s.producers * s.events
+	rc.Add(events)
+
+	b.StartTimer()
+	start := time.Now()
+	for _, pid := range producers {
+		if err := producerNode.Send(pid, startPublish{}); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		rc.Wait()
+		close(done)
+	}()
+
+	var backlog int64
+	ticker := time.NewTicker(backlogInterval)
+	defer ticker.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-ticker.C:
+			backlog = max(backlog, s.maxBacklog())
+		}
+	}
+	totalDuration := b.StopTimer()
+	<-s.publishDone
+	publishDuration := rc.Time(markPublished).Sub(start)
+
+	total := s.total()
+	fmt.Printf("\n")
+	fmt.Printf("Total subscribers:       %d\n", total)
+	fmt.Printf("Consumer nodes:          %d\n", s.nodes)
+	fmt.Printf("Subscribers per node:    %d\n", s.subscribers)
+	fmt.Printf("Producers:               %d\n", s.producers)
+	fmt.Printf("Events published:        %d\n", events)
+	fmt.Printf("Network messages sent:   %d (1 per consumer node and event)\n", s.nodes*events)
+
+	b.SetOps(total * events)
+	b.ReportDuration("publish", publishDuration)
+	b.ReportDuration("deliver all", totalDuration)
+	b.ReportMetric("delivery rate", float64(total*events)/totalDuration.Seconds(), "msg/sec")
+	if events == 1 {
+		return nil
+	}
+	b.ReportMetric("event rate", float64(events)/totalDuration.Seconds(), "event/sec")
+	b.ReportLatency("event latency", s.eventLatency)
+	b.ReportLatency("delivery latency", s.deliveryLatency)
+	b.ReportMetric("backlog max", float64(backlog), "event")
+	// the events not delivered by the time the producers are done, the ones
+	// piling up while publishing
+	b.ReportMetric("backlog growth", float64(s.backlog.Load())/publishDuration.Seconds(), "+event/sec")
+	return nil
+}
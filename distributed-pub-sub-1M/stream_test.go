@@ -0,0 +1,59 @@
+package pubsub
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"harness"
+)
+
+func TestMaxBacklog(t *testing.T) {
+	s := &stream{subscribers: 2, received: make([]atomic.Int64, 3)}
+	s.published.Store(10)
+	s.received[0].Store(20) // all the events are received by both subscribers
+	s.received[1].Store(8)  // 4 events
+	s.received[2].Store(15) // 7 events, one of the subscribers is behind
+	if backlog := s.maxBacklog(); backlog != 6 {
+		t.Fatalf("expected backlog 6, got %d", backlog)
+	}
+}
+
+func TestStream(t *testing.T) {
+	// 30 subscribers on 3 nodes
+	r, err := harness.RunOnce("pubsub/small", harness.Params{"events": 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 30*200 {
+		t.Fatalf("expected %d deliveries, got %d", 30*200, r.Ops)
+	}
+	if m, found := r.Metric("event rate"); found == false || m.Value <= 0 {
+		t.Fatalf("expected positive event rate, got %f", m.Value)
+	}
+	if m, _ := r.Metric("event latency max"); m.Value <= 0 {
+		t.Fatalf("expected the event latency to be recorded")
+	}
+	if m, found := r.Metric("backlog max"); found == false || m.Value < 0 || m.Value > 200 {
+		t.Fatalf("expected the backlog within the 200 events, got %.0f", m.Value)
+	}
+	// the publish duration is measured once the producers are done
+	if m, found := r.Metric("backlog growth"); found == false || m.Value < 0 || math.IsInf(m.Value, 0) || math.IsNaN(m.Value) {
+		t.Fatalf("expected the backlog growth of the publish duration, got %f", m.Value)
+	}
+}
+
+func TestStreamRate(t *testing.T) {
+	// the last of 100 events is due in 99ms
+	r, err := harness.RunOnce("pubsub/small", harness.Params{"events": 100, "rate": 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 30*100 {
+		t.Fatalf("expected %d deliveries, got %d", 30*100, r.Ops)
+	}
+	if r.Elapsed < 99*time.Millisecond {
+		t.Fatalf("expected the events published on schedule within 99ms at least, got %s", r.Elapsed)
+	}
+}
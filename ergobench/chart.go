@@ -0,0 +1,287 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"harness"
+)
+
+type chartWriter interface {
+	WriteSVG(w io.Writer) error
+}
+
+func chart(args []string) error {
+	fs := flag.NewFlagSet("chart", flag.ContinueOnError)
+	dir := fs.String("o", ".", "directory to write the charts to")
+	serial := fs.String("serial", "", "output of the serialization benchmarks (go test -bench . -benchmem in the serial directory)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ergobench chart [flags] [results.json ...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 && *serial == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var results []harness.Result
+	for _, path := range fs.Args() {
+		r, err := harness.ReadResults(path)
+		if err != nil {
+			return err
+		}
+		results = append(results, r...)
+	}
+
+	charts := make(map[string]chartWriter)
+	for unit, c := range pingCharts(results) {
+		charts["ping-"+strings.TrimSuffix(unit, "/sec")+".svg"] = c
+	}
+	if c, found := memusageChart(results); found {
+		charts["memusage.svg"] = c
+	}
+	if c, found := pubsubChart(results); found {
+		charts["pubsub.svg"] = c
+	}
+	if *serial != "" {
+		c, err := serialChart(*serial)
+		if err != nil {
+			return err
+		}
+		charts["serial.svg"] = c
+	}
+	if len(charts) == 0 {
+		return fmt.Errorf("nothing to chart: no ping, memusage or pubsub results")
+	}
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(charts))
+	for name := range charts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(*dir, name)
+		if err := writeChart(path, charts[name]); err != nil {
+			return err
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+func writeChart(path string, c chartWriter) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.WriteSVG(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// pingCharts returns a bar chart of the throughput of the ping scenarios
+// for every throughput unit (msg/sec, rtt/sec, calls/sec).
+func pingCharts(results []harness.Result) map[string]harness.BarChart {
+	charts := make(map[string]harness.BarChart)
+
+	for _, g := range groupByRun(results, "ping/") {
+		s, found := metricSummary(g.results, "throughput")
+		if found == false {
+			continue
+		}
+		c, exist := charts[s.Unit]
+		if exist == false {
+			c = harness.BarChart{
+				Title:  "Ping: throughput (" + s.Unit + ")",
+				Unit:   s.Unit,
+				Series: []harness.Series{{}},
+			}
+		}
+		c.Categories = append(c.Categories, g.label)
+		c.Series[0].Values = append(c.Series[0].Values, s.Mean)
+		c.Series[0].Errors = append(c.Series[0].Errors, s.CI)
+		charts[s.Unit] = c
+	}
+	return charts
+}
+
+// memusageChart returns the memory per process against the number of
+// processes for every memusage scenario.
+func memusageChart(results []harness.Result) (harness.LineChart, bool) {
+	c := harness.LineChart{
+		Title:  "Memory usage per process",
+		XLabel: "processes",
+		YUnit:  "KB",
+	}
+
+	lines := make(map[string]int)
+	for _, g := range groupByRun(results, "memusage") {
+		s, found := metricSummary(g.results, "memory per process")
+		if found == false {
+			continue
+		}
+		processes, found := paramFloat(g.results[0].Params["processes"])
+		if found == false {
+			continue
+		}
+
+		scenario := g.results[0].Scenario
+		i, exist := lines[scenario]
+		if exist == false {
+			i = len(c.Lines)
+			lines[scenario] = i
+			c.Lines = append(c.Lines, harness.Line{Name: scenario})
+		}
+		c.Lines[i].Points = append(c.Lines[i].Points, harness.Point{X: processes, Y: s.Mean})
+	}
+
+	for _, l := range c.Lines {
+		sort.Slice(l.Points, func(i, j int) bool { return l.Points[i].X < l.Points[j].X })
+	}
+	return c, len(c.Lines) > 0
+}
+
+// pubsubChart returns the timeline of the pub/sub scenarios: subscribing,
+// publishing and the delivery to all the subscribers.
+func pubsubChart(results []harness.Result) (harness.Timeline, bool) {
+	c := harness.Timeline{Title: "Pub/Sub: subscribe, publish and deliver"}
+
+	for _, g := range groupByRun(results, "pubsub/") {
+		subscribe, found1 := metricSummary(g.results, "subscribe")
+		publish, found2 := metricSummary(g.results, "publish")
+		deliver, found3 := metricSummary(g.results, "deliver all")
+		if found1 == false || found2 == false || found3 == false {
+			continue
+		}
+
+		start := time.Duration(subscribe.Mean)
+		c.Rows = append(c.Rows, harness.TimelineRow{
+			Name: g.label,
+			Spans: []harness.Span{
+				{Name: "subscribe", Start: 0, End: start},
+				{Name: "publish", Start: start, End: start + time.Duration(publish.Mean)},
+				{Name: "deliver all", Start: start, End: start + time.Duration(deliver.Mean)},
+			},
+		})
+	}
+	return c, len(c.Rows) > 0
+}
+
+// serialChart returns the time per operation of every codec for every
+// serialization benchmark case.
+func serialChart(path string) (harness.BarChart, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return harness.BarChart{}, err
+	}
+	defer f.Close()
+
+	lines, err := harness.ParseBench(f)
+	if err != nil {
+		return harness.BarChart{}, err
+	}
+	results := harness.SerialResults(lines)
+	if len(results) == 0 {
+		return harness.BarChart{}, fmt.Errorf("%s: no serialization benchmarks found", path)
+	}
+
+	c := harness.BarChart{
+		Title: "Serialization: EDF vs Protobuf vs Gob (time per operation)",
+		Unit:  "ns/op",
+	}
+	cases := make(map[string]int)
+	for _, codec := range harness.Codecs {
+		c.Series = append(c.Series, harness.Series{Name: codec})
+	}
+	for _, r := range results {
+		i, exist := cases[r.Case()]
+		if exist == false {
+			i = len(c.Categories)
+			cases[r.Case()] = i
+			c.Categories = append(c.Categories, r.Case())
+			for j := range c.Series {
+				c.Series[j].Values = append(c.Series[j].Values, math.NaN())
+			}
+		}
+		for j, codec := range harness.Codecs {
+			if codec == r.Codec {
+				c.Series[j].Values[i] = r.NsOp
+			}
+		}
+	}
+	return c, nil
+}
+
+type runGroup struct {
+	label   string
+	results []harness.Result
+}
+
+// groupByRun groups the iterations of the scenarios matching the prefix by
+// the scenario and its parameters in the order of appearance.
+func groupByRun(results []harness.Result, prefix string) []runGroup {
+	var groups []runGroup
+	index := make(map[string]int)
+	for _, r := range results {
+		if strings.HasPrefix(r.Scenario, prefix) == false {
+			continue
+		}
+		key := r.Scenario + " " + r.Params.String()
+		i, exist := index[key]
+		if exist == false {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, runGroup{label: runLabel(r)})
+		}
+		groups[i].results = append(groups[i].results, r)
+	}
+	return groups
+}
+
+// runLabel returns the scenario name followed by the parameters that differ
+// from the defaults.
+func runLabel(r harness.Result) string {
+	label := r.Scenario
+	changed := r.ChangedParams()
+	for _, name := range changed.Names() {
+		label += " " + name + "=" + harness.FormatParam(changed[name])
+	}
+	return label
+}
+
+func paramFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func metricSummary(results []harness.Result, name string) (harness.Summary, bool) {
+	for _, s := range harness.Summarize(results) {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return harness.Summary{}, false
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"harness"
+)
+
+var errRegression = errors.New("performance regression detected")
+
+func compare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	thresholds := thresholdFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ergobench compare [flags] baseline.json current.json\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	base, err := harness.ReadResults(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	current, err := harness.ReadResults(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return printComparison(base, current, *thresholds)
+}
+
+// thresholdFlags adds the flags of the regression thresholds to the flag set.
+func thresholdFlags(fs *flag.FlagSet) *harness.Thresholds {
+	t := harness.DefaultThresholds
+	fs.Float64Var(&t.Throughput, "throughput", t.Throughput, "allowed drop of the throughput, %")
+	fs.Float64Var(&t.Latency, "latency", t.Latency, "allowed rise of the latency and durations, %")
+	fs.Float64Var(&t.Memory, "memory", t.Memory, "allowed rise of the memory usage, %")
+	fs.Float64Var(&t.Alpha, "alpha", t.Alpha, "significance level of the t-test (applies if both sides have 2+ iterations)")
+	return &t
+}
+
+// printComparison prints the change of every metric and returns errRegression
+// if any of them got worse beyond the threshold.
+func printComparison(base []harness.Result, current []harness.Result, thresholds harness.Thresholds) error {
+	comparisons := harness.Compare(base, current, thresholds)
+	if len(comparisons) == 0 {
+		return fmt.Errorf("no scenarios to compare: the results have no scenarios with the same parameters")
+	}
+
+	regressions := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "SCENARIO\tMETRIC\tBASELINE\tCURRENT\tDELTA\tP\t\n")
+	last := ""
+	for _, c := range comparisons {
+		scenario := c.Scenario + " " + c.Params
+		if scenario == last {
+			scenario = ""
+		} else {
+			last = scenario
+		}
+
+		status := ""
+		if c.Regression {
+			status = "REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			scenario, c.Current.Name,
+			formatMean(c.Base), formatMean(c.Current),
+			formatPercent(c.Delta), formatP(c.P), status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// the results are comparable if they are made in the same environment
+	if diff := base[0].Env.Diff(current[0].Env); len(diff) > 0 {
+		fmt.Printf("\nThe environment differs from the baseline:\n")
+		for _, d := range diff {
+			fmt.Printf("  %s\n", d)
+		}
+	}
+
+	if regressions > 0 {
+		return fmt.Errorf("%w (%d of %d metrics)", errRegression, regressions, len(comparisons))
+	}
+	return nil
+}
+
+func formatMean(s harness.Summary) string {
+	mean := harness.Metric{Value: s.Mean, Unit: s.Unit}.String()
+	if s.N < 2 || s.Mean == 0 {
+		return mean
+	}
+	return fmt.Sprintf("%s ±%.1f%%", mean, 100*s.CI/math.Abs(s.Mean))
+}
+
+func formatPercent(delta float64) string {
+	if math.IsNaN(delta) {
+		return "~"
+	}
+	return fmt.Sprintf("%+.2f%%", delta)
+}
+
+func formatP(p float64) string {
+	if math.IsNaN(p) {
+		return "~"
+	}
+	return fmt.Sprintf("%.3f", p)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"harness"
+)
+
+func list(patterns []string) error {
+	scenarios, err := selectScenarios(patterns)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, s := range scenarios {
+		fmt.Fprintf(w, "%s\t%s\n", s.Name, s.Description)
+		for _, p := range s.Params {
+			fmt.Fprintf(w, "  -%s %s\t%s (default %v)\n", p.Name, p.Type(), p.Usage, p.Default)
+		}
+	}
+	return w.Flush()
+}
+
+// selectScenarios returns the scenarios matching the patterns.
+// All scenarios are returned if there are no patterns.
+func selectScenarios(patterns []string) ([]harness.Scenario, error) {
+	var selected []harness.Scenario
+
+	if len(patterns) == 0 {
+		for _, name := range harness.Names() {
+			s, _ := harness.Lookup(name)
+			selected = append(selected, s)
+		}
+		return selected, nil
+	}
+
+	for _, pattern := range patterns {
+		scenarios, err := harness.Match(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(scenarios) == 0 {
+			return nil, fmt.Errorf("no scenarios match %q", pattern)
+		}
+		selected = append(selected, scenarios...)
+	}
+	return selected, nil
+}
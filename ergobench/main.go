@@ -0,0 +1,82 @@
+// Command ergobench runs the benchmark scenarios of the Ergo Framework.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "distributed-pub-sub-1M"
+	"harness"
+	_ "memusage"
+	_ "ping"
+	_ "supervision"
+)
+
+const usage = `Usage: ergobench <command> [arguments]
+
+Commands:
+  list [pattern ...]                          list the scenarios and their parameters
+  run [flags] [pattern [scenario flags] ...]  run the scenarios (all if no pattern is given)
+  scaling [flags] [pattern [flags] ...]       run the scenarios across GOMAXPROCS values (scalability)
+  compare [flags] baseline.json current.json  compare the results and detect regressions
+  chart [flags] [results.json ...]            render the results as SVG charts
+  serial [flags] [bench.txt | -]              make the markdown table of the serialization benchmarks
+
+A pattern is a scenario name (ping/local-11), a group of scenarios (ping)
+or a glob (ping/network-*). The flags following a pattern set the parameters
+of the scenarios it selects, e.g.
+
+  ergobench run ping/local-11 memusage -processes 100000 pubsub/1M
+
+Run "ergobench run -h" to see the flags of the run command.
+The compare command exits with code 1 if any metric got worse beyond
+the threshold ("ergobench compare -h" shows them).
+`
+
+func main() {
+	if harness.IsChild() {
+		// started by a scenario to run a part of it in a separate OS process
+		if err := harness.RunChild(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Args[2:])
+	case "run":
+		err = run(os.Args[2:])
+	case "scaling":
+		err = scaling(os.Args[2:])
+	case "compare":
+		err = compare(os.Args[2:])
+	case "chart":
+		err = chart(os.Args[2:])
+	case "serial":
+		err = serial(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"harness"
+)
+
+type job struct {
+	scenario harness.Scenario
+	params   harness.Params
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	pause := fs.Duration("pause", 10*time.Second, "pause between the scenarios to let the system settle down")
+	output := fs.String("o", "", "write the results to the file")
+	format := fs.String("format", "", "format of the results file: json, csv or bench (default: by the file extension, json otherwise)")
+	benchfmt := fs.Bool("benchfmt", false, "print the results in the Go benchmark format (for benchstat)")
+	count := fs.Int("count", 1, "run each scenario n times and print the summary of the iterations")
+	warmup := fs.Int("warmup", 0, "run each scenario n times before the measured iterations and drop the results")
+	baseline := fs.String("baseline", "", "compare the results with the baseline file (JSON) and fail on regression")
+	profile := fs.String("profile", "", "capture the profiles within the measured window: "+strings.Join(harness.ProfileKinds, ",")+" or all")
+	profileDir := fs.String("profiledir", "profiles", "directory for the profiles, each scenario has its own subdirectory")
+	thresholds := thresholdFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ergobench run [flags] [pattern [scenario flags] ...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *count < 1 {
+		return fmt.Errorf("count must be positive")
+	}
+	if *warmup < 0 {
+		return fmt.Errorf("warmup must not be negative")
+	}
+
+	profiles, err := harness.ParseProfiles(*profile, *profileDir)
+	if err != nil {
+		return err
+	}
+	harness.SetProfiles(profiles)
+
+	jobs, err := parseJobs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	var base []harness.Result
+	if *baseline != "" {
+		// read it before the long run to fail early
+		base, err = harness.ReadResults(*baseline)
+		if err != nil {
+			return err
+		}
+	}
+
+	results, err := runJobs(jobs, *pause, *warmup, *count, *benchfmt)
+	if err != nil {
+		// keep the results of the scenarios that are already done
+		if *output != "" {
+			harness.WriteResults(*output, *format, results)
+		}
+		return err
+	}
+
+	if *output != "" {
+		if err := harness.WriteResults(*output, *format, results); err != nil {
+			return err
+		}
+	}
+	if *baseline == "" {
+		return nil
+	}
+	fmt.Printf("\n")
+	return printComparison(base, results, *thresholds)
+}
+
+// runJobs runs the jobs one by one with the pause between them. On error,
+// the results of the jobs that are already done are returned as well.
+func runJobs(jobs []job, pause time.Duration, warmup int, count int, benchfmt bool) ([]harness.Result, error) {
+	var results []harness.Result
+	for i, j := range jobs {
+		if i > 0 && pause > 0 {
+			time.Sleep(pause)
+		}
+		iterations, err := harness.RunIterations(j.scenario, j.params, warmup, count)
+		results = append(results, iterations...)
+		if err != nil {
+			return results, err
+		}
+
+		if benchfmt {
+			if i == 0 {
+				harness.WriteBenchHeader(os.Stdout, iterations[0].Env)
+			}
+			for _, result := range iterations {
+				harness.WriteBenchResult(os.Stdout, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+// parseJobs turns the list of patterns, each followed by optional scenario
+// flags, into the list of scenarios to run. The flags following a pattern are
+// applied to the scenarios matched by this pattern that declare them. A flag with the list of
+// values (-pings 1,2,4) turns into a job for each of them.
+func parseJobs(args []string) ([]job, error) {
+	var jobs []job
+
+	if len(args) == 0 {
+		scenarios, _ := selectScenarios(nil)
+		for _, s := range scenarios {
+			sweep, _, err := expandGroup(s.Name, []harness.Scenario{s}, nil)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, sweep...)
+		}
+		return jobs, nil
+	}
+
+	for len(args) > 0 {
+		pattern := args[0]
+		if strings.HasPrefix(pattern, "-") {
+			return nil, fmt.Errorf("flag %s must follow a scenario name", pattern)
+		}
+
+		scenarios, err := selectScenarios([]string{pattern})
+		if err != nil {
+			return nil, err
+		}
+
+		sweep, rest, err := expandGroup(pattern, scenarios, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, sweep...)
+		args = rest
+	}
+
+	return jobs, nil
+}
+
+// expandGroup parses the flags following the pattern and expands every
+// scenario it selects. A flag applies to the scenarios declaring the parameter
+// and is ignored by the rest, e.g. "ping -messages 1000" doesn't set anything
+// for the call scenarios. A flag declared by none of them is an error.
+func expandGroup(pattern string, scenarios []harness.Scenario, args []string) ([]job, []string, error) {
+	group := flag.NewFlagSet(pattern, flag.ContinueOnError)
+	values := make([]harness.Values, len(scenarios))
+	for i, s := range scenarios {
+		values[i] = make(harness.Values)
+		if err := s.SetFromEnv(values[i]); err != nil {
+			return nil, nil, err
+		}
+		fs := s.FlagSet(values[i])
+		fs.VisitAll(func(f *flag.Flag) {
+			if g := group.Lookup(f.Name); g != nil {
+				gf := g.Value.(*groupFlag)
+				gf.flags = append(gf.flags, f)
+				return
+			}
+			group.Var(&groupFlag{flags: []*flag.Flag{f}}, f.Name, f.Usage)
+		})
+	}
+	if err := group.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	var jobs []job
+	for i, s := range scenarios {
+		sweep, err := s.Expand(values[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, params := range sweep {
+			jobs = append(jobs, job{scenario: s, params: params})
+		}
+	}
+	return jobs, group.Args(), nil
+}
+
+// groupFlag sets the flag of every scenario declaring it.
+type groupFlag struct {
+	flags []*flag.Flag
+}
+
+func (g *groupFlag) String() string {
+	if g == nil || len(g.flags) == 0 {
+		return ""
+	}
+	return g.flags[0].Value.String()
+}
+
+func (g *groupFlag) Set(s string) error {
+	for _, f := range g.flags {
+		if err := f.Value.Set(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *groupFlag) IsBoolFlag() bool {
+	for _, f := range g.flags {
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			return true
+		}
+	}
+	return false
+}
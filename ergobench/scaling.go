@@ -0,0 +1,274 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"harness"
+)
+
+// scalingDefault are the scenarios of the scaling command if none is given
+var scalingDefault = []string{"ping/local-NN", "ping/network-NN"}
+
+func scaling(args []string) error {
+	fs := flag.NewFlagSet("scaling", flag.ContinueOnError)
+	cpu := fs.String("cpu", powersOfTwo(runtime.NumCPU()), "list of GOMAXPROCS values")
+	processes := fs.String("processes", "", "list of the numbers of ping and pong processes (default: the same as GOMAXPROCS)")
+	pause := fs.Duration("pause", 10*time.Second, "pause between the runs to let the system settle down")
+	output := fs.String("o", "", "write the results to the file")
+	format := fs.String("format", "", "format of the results file: json, csv or bench (default: by the file extension, json otherwise)")
+	count := fs.Int("count", 1, "run each combination n times")
+	warmup := fs.Int("warmup", 0, "run each combination n times before the measured iterations and drop the results")
+	charts := fs.String("chart", "", "write the scalability and efficiency charts (SVG) to the directory")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ergobench scaling [flags] [pattern [scenario flags] ...]\n\n")
+		fmt.Fprintf(os.Stderr, "Runs the scenarios (%s by default) for every GOMAXPROCS\n", strings.Join(scalingDefault, ", "))
+		fmt.Fprintf(os.Stderr, "value and number of processes and shows how the throughput scales.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *count < 1 {
+		return fmt.Errorf("count must be positive")
+	}
+
+	procs, err := parseInts(*cpu)
+	if err != nil || len(procs) == 0 {
+		return fmt.Errorf("invalid -cpu %q: expected a list of positive numbers", *cpu)
+	}
+	var counts []int
+	if *processes != "" {
+		counts, err = parseInts(*processes)
+		if err != nil {
+			return fmt.Errorf("invalid -processes %q: expected a list of positive numbers", *processes)
+		}
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = scalingDefault
+	}
+	base, err := parseJobs(patterns)
+	if err != nil {
+		return err
+	}
+
+	var jobs []job
+	for _, j := range base {
+		defaults := j.scenario.DefaultParams()
+		if _, found := defaults["gomaxprocs"]; found == false {
+			return fmt.Errorf("%s has no gomaxprocs parameter", j.scenario.Name)
+		}
+		for _, n := range procs {
+			if counts == nil {
+				jobs = append(jobs, scalingJob(j, n, n))
+				continue
+			}
+			for _, c := range counts {
+				jobs = append(jobs, scalingJob(j, n, c))
+			}
+		}
+	}
+
+	results, err := runJobs(jobs, *pause, *warmup, *count, false)
+	if *output != "" {
+		if err := harness.WriteResults(*output, *format, results); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	points := scalingPoints(results, counts == nil)
+	fmt.Printf("\n")
+	if err := printScaling(points); err != nil {
+		return err
+	}
+	if *charts == "" {
+		return nil
+	}
+	return writeScalingCharts(*charts, points)
+}
+
+// scalingJob returns the job with GOMAXPROCS and the number of ping and pong
+// processes set.
+func scalingJob(j job, procs int, processes int) job {
+	params := make(harness.Params)
+	for name, value := range j.params {
+		params[name] = value
+	}
+	params["gomaxprocs"] = procs
+	if _, found := params["pings"]; found {
+		params["pings"] = processes
+	}
+	if _, found := params["pongs"]; found {
+		params["pongs"] = processes
+	}
+	return job{scenario: j.scenario, params: params}
+}
+
+// scalingPoint is the throughput of the scenario run with the GOMAXPROCS and
+// the number of processes.
+type scalingPoint struct {
+	scenario   string
+	series     string
+	procs      int
+	processes  int
+	throughput harness.Summary
+	// speedup and efficiency relative to the first GOMAXPROCS of the series
+	speedup    float64
+	efficiency float64
+}
+
+// scalingPoints groups the results into the series: a series per scenario and
+// number of processes (or a single one per scenario if the number of processes
+// follows GOMAXPROCS).
+func scalingPoints(results []harness.Result, follow bool) []scalingPoint {
+	var points []scalingPoint
+	first := make(map[string]int)
+
+	for _, g := range groupByRun(results, "") {
+		s, found := metricSummary(g.results, "throughput")
+		if found == false {
+			continue
+		}
+		r := g.results[0]
+		p := scalingPoint{
+			scenario:   r.Scenario,
+			procs:      r.Env.GOMAXPROCS,
+			throughput: s,
+		}
+		if v, found := paramFloat(r.Params["pings"]); found {
+			p.processes = int(v)
+		}
+		p.series = fmt.Sprintf("N = %d", p.processes)
+		if follow {
+			p.series = "N = GOMAXPROCS"
+		}
+
+		key := p.scenario + " " + p.series
+		i, exist := first[key]
+		if exist == false {
+			i = len(points)
+			first[key] = i
+		}
+		base := p
+		if exist {
+			base = points[i]
+		}
+		p.speedup = p.throughput.Mean / base.throughput.Mean
+		p.efficiency = 100 * p.speedup / (float64(p.procs) / float64(base.procs))
+		points = append(points, p)
+	}
+	return points
+}
+
+func printScaling(points []scalingPoint) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "SCENARIO\tGOMAXPROCS\tPROCESSES\tTHROUGHPUT\tSPEEDUP\tEFFICIENCY\t\n")
+	last := ""
+	for _, p := range points {
+		scenario := p.scenario
+		if scenario == last {
+			scenario = ""
+		} else {
+			last = scenario
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%.2fx\t%.0f%%\t\n",
+			scenario, p.procs, p.processes, formatMean(p.throughput), p.speedup, p.efficiency)
+	}
+	return w.Flush()
+}
+
+// writeScalingCharts writes the throughput and the parallel efficiency against
+// GOMAXPROCS for every scenario.
+func writeScalingCharts(dir string, points []scalingPoint) error {
+	var scenarios []string
+	throughput := make(map[string]*harness.LineChart)
+	efficiency := make(map[string]*harness.LineChart)
+
+	for _, p := range points {
+		t, exist := throughput[p.scenario]
+		if exist == false {
+			scenarios = append(scenarios, p.scenario)
+			t = &harness.LineChart{
+				Title:  p.scenario + ": throughput",
+				XLabel: "GOMAXPROCS",
+				YUnit:  p.throughput.Unit,
+			}
+			throughput[p.scenario] = t
+			efficiency[p.scenario] = &harness.LineChart{
+				Title:  p.scenario + ": parallel efficiency",
+				XLabel: "GOMAXPROCS",
+				YUnit:  "%",
+			}
+		}
+		addPoint(t, p.series, harness.Point{X: float64(p.procs), Y: p.throughput.Mean})
+		addPoint(efficiency[p.scenario], p.series, harness.Point{X: float64(p.procs), Y: p.efficiency})
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, scenario := range scenarios {
+		name := strings.ReplaceAll(scenario, "/", "-")
+		for _, c := range []struct {
+			prefix string
+			chart  *harness.LineChart
+		}{
+			{"scaling-", throughput[scenario]},
+			{"efficiency-", efficiency[scenario]},
+		} {
+			path := filepath.Join(dir, c.prefix+name+".svg")
+			if err := writeChart(path, c.chart); err != nil {
+				return err
+			}
+			fmt.Println(path)
+		}
+	}
+	return nil
+}
+
+func addPoint(c *harness.LineChart, line string, p harness.Point) {
+	for i := range c.Lines {
+		if c.Lines[i].Name == line {
+			c.Lines[i].Points = append(c.Lines[i].Points, p)
+			return
+		}
+	}
+	c.Lines = append(c.Lines, harness.Line{Name: line, Points: []harness.Point{p}})
+}
+
+// powersOfTwo returns "1,2,4,...,n" (n is added if it's not a power of two).
+func powersOfTwo(n int) string {
+	var list []string
+	i := 1
+	for ; i < n; i *= 2 {
+		list = append(list, strconv.Itoa(i))
+	}
+	return strings.Join(append(list, strconv.Itoa(n)), ",")
+}
+
+func parseInts(s string) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		if v < 1 {
+			return nil, fmt.Errorf("%d is not positive", v)
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"harness"
+)
+
+// markers of the generated table in the markdown file
+const (
+	serialBegin = "<!-- serial-table:begin -->"
+	serialEnd   = "<!-- serial-table:end -->"
+)
+
+func serial(args []string) error {
+	fs := flag.NewFlagSet("serial", flag.ContinueOnError)
+	dir := fs.String("dir", "../serial", "directory of the serialization benchmarks, they are run if no file is given")
+	update := fs.String("update", "", "replace the table in the markdown file (between the "+serialBegin+" and "+serialEnd+" lines)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ergobench serial [flags] [bench.txt | -]\n\n")
+		fmt.Fprintf(os.Stderr, "Makes the markdown table of the serialization benchmarks from the output of\n")
+		fmt.Fprintf(os.Stderr, "\"go test -bench . -benchmem\" (read from the file, stdin or by running them).\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var output []byte
+	var err error
+	switch {
+	case fs.NArg() > 1:
+		fs.Usage()
+		return flag.ErrHelp
+	case fs.Arg(0) == "-":
+		output, err = io.ReadAll(os.Stdin)
+	case fs.NArg() == 1:
+		output, err = os.ReadFile(fs.Arg(0))
+	default:
+		output, err = runSerial(*dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	lines, err := harness.ParseBench(bytes.NewReader(output))
+	if err != nil {
+		return err
+	}
+	results := harness.SerialResults(lines)
+	if len(results) == 0 {
+		return fmt.Errorf("no serialization benchmarks found")
+	}
+
+	var table bytes.Buffer
+	if err := harness.WriteSerialTable(&table, results, benchCPU(output)); err != nil {
+		return err
+	}
+
+	if *update == "" {
+		_, err := os.Stdout.Write(table.Bytes())
+		return err
+	}
+	return updateMarkdown(*update, table.String())
+}
+
+// runSerial runs the benchmarks in the directory and returns the output.
+// The output is shown on stderr as well, since it takes a while.
+func runSerial(dir string) ([]byte, error) {
+	var output bytes.Buffer
+	cmd := exec.Command("go", "test", "-run", "^$", "-bench", ".", "-benchmem")
+	cmd.Dir = dir
+	cmd.Stdout = io.MultiWriter(&output, os.Stderr)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("unable to run the benchmarks in %s: %w", dir, err)
+	}
+	return output.Bytes(), nil
+}
+
+// benchCPU returns the value of the "cpu:" configuration line.
+func benchCPU(output []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if cpu, found := strings.CutPrefix(scanner.Text(), "cpu:"); found {
+			return strings.TrimSpace(cpu)
+		}
+	}
+	return ""
+}
+
+// updateMarkdown replaces the text between the markers in the file.
+func updateMarkdown(path string, table string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	text := string(data)
+
+	begin := strings.Index(text, serialBegin)
+	end := strings.Index(text, serialEnd)
+	if begin < 0 || end < begin {
+		return fmt.Errorf("%s: no %s and %s lines", path, serialBegin, serialEnd)
+	}
+	text = text[:begin+len(serialBegin)] + "\n" + table + text[end:]
+	return os.WriteFile(path, []byte(text), 0644)
+}
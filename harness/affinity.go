@@ -0,0 +1,93 @@
+package harness
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// SetAffinity pins the process to the CPUs given as a list like "0-3,8,10".
+// GOMAXPROCS is set to the number of the CPUs as well. It is supported on
+// Linux only.
+func SetAffinity(cpus string) error {
+	list, err := parseCPUList(cpus)
+	if err != nil {
+		return err
+	}
+	if err := setAffinity(list); err != nil {
+		return err
+	}
+	runtime.GOMAXPROCS(len(list))
+	return nil
+}
+
+// SetAffinity pins the process to the CPUs the same way the package-level
+// SetAffinity does, but the original affinity and GOMAXPROCS are restored
+// once the scenario is finished.
+func (b *B) SetAffinity(cpus string) error {
+	if b.affinity == nil {
+		current, err := getAffinity()
+		if err != nil {
+			return err
+		}
+		b.affinity = current
+	}
+	if b.maxprocs == 0 {
+		b.maxprocs = runtime.GOMAXPROCS(0)
+	}
+	if err := SetAffinity(cpus); err != nil {
+		return err
+	}
+	b.result.Env.GOMAXPROCS = runtime.GOMAXPROCS(0)
+	fmt.Printf("CPU affinity: %s (GOMAXPROCS: %d)\n", cpus, b.result.Env.GOMAXPROCS)
+	return nil
+}
+
+// SetGOMAXPROCS sets GOMAXPROCS for the scenario. The original value is
+// restored once the scenario is finished. It's meant to be called before
+// the nodes are started, the value is printed since the banner shows the
+// original one.
+func (b *B) SetGOMAXPROCS(n int) {
+	if b.maxprocs == 0 {
+		b.maxprocs = runtime.GOMAXPROCS(0)
+	}
+	runtime.GOMAXPROCS(n)
+	b.result.Env.GOMAXPROCS = n
+	fmt.Printf("GOMAXPROCS: %d (set by the scenario)\n", n)
+}
+
+func (b *B) restoreAffinity() {
+	if b.affinity != nil {
+		setAffinity(b.affinity)
+		b.affinity = nil
+	}
+	if b.maxprocs > 0 {
+		runtime.GOMAXPROCS(b.maxprocs)
+		b.maxprocs = 0
+	}
+}
+
+func parseCPUList(s string) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		first, last, isRange := strings.Cut(item, "-")
+
+		from, err := strconv.Atoi(first)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("invalid CPU list %q", s)
+		}
+		to := from
+		if isRange {
+			to, err = strconv.Atoi(last)
+			if err != nil || to < from {
+				return nil, fmt.Errorf("invalid CPU list %q", s)
+			}
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			list = append(list, cpu)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package harness
+
+import "errors"
+
+var errAffinity = errors.New("CPU affinity is supported on Linux only")
+
+func setAffinity(cpus []int) error {
+	return errAffinity
+}
+
+func getAffinity() ([]int, error) {
+	return nil, errAffinity
+}
@@ -0,0 +1,137 @@
+package harness
+
+import (
+	"time"
+
+	"ergo.services/ergo"
+	"ergo.services/ergo/gen"
+	"ergo.services/logger/colored"
+)
+
+const (
+	// Cookie is used by all the nodes started by the harness unless
+	// the scenario sets its own one.
+	Cookie = "cookie"
+)
+
+// B is passed to the Run function of a scenario. It keeps the state of a single
+// run: the nodes started by the scenario, the measured window and the metrics.
+type B struct {
+	nodes    []gen.Node
+	children []child
+	affinity []int
+	maxprocs int
+	profiler *profiler
+	start    time.Time
+	elapsed  time.Duration
+	result   Result
+}
+
+// StartNode starts a node with the colored logger. The default cookie is used
+// if the given options have none. The node is stopped once the scenario is
+// finished.
+func (b *B) StartNode(name gen.Atom, options gen.NodeOptions) (gen.Node, error) {
+	node, err := StartNode(name, options)
+	if err != nil {
+		return nil, err
+	}
+	b.nodes = append(b.nodes, node)
+	return node, nil
+}
+
+// StartNode starts a node the same way B.StartNode does, but it's up to
+// the caller to stop it. It is used by the roles running in a child process.
+func StartNode(name gen.Atom, options gen.NodeOptions) (gen.Node, error) {
+	if options.Network.Cookie == "" {
+		options.Network.Cookie = Cookie
+	}
+
+	loggercolored, err := colored.CreateLogger(colored.Options{
+		TimeFormat:    time.DateTime,
+		DisableBanner: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	options.Log.DefaultLogger.Disable = true
+	options.Log.Loggers = append(
+		options.Log.Loggers,
+		gen.Logger{Name: "colored", Logger: loggercolored},
+	)
+
+	return ergo.StartNode(name, options)
+}
+
+// WaitProcesses waits until the number of the processes on the node drops to
+// n, e.g. the terminated processes are cleaned up, and returns the node
+// information. It gives up after the timeout and returns the latest one.
+func WaitProcesses(node gen.Node, n int64, timeout time.Duration) (gen.NodeInfo, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := node.Info()
+		if err != nil || info.ProcessesTotal <= n || time.Now().After(deadline) {
+			return info, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// StartTimer marks the beginning of the measured window. The profiles
+// enabled by SetProfiles are started here.
+func (b *B) StartTimer() {
+	if b.profiler != nil {
+		b.profiler.start()
+	}
+	b.start = time.Now()
+}
+
+// StopTimer marks the end of the measured window and returns its duration.
+// The profiles are stopped and written here.
+func (b *B) StopTimer() time.Duration {
+	b.elapsed = time.Since(b.start)
+	if b.profiler != nil {
+		b.profiler.stop()
+	}
+	return b.elapsed
+}
+
+// Elapsed returns the duration of the measured window.
+func (b *B) Elapsed() time.Duration {
+	return b.elapsed
+}
+
+// SetOps sets the number of operations (messages, spawned processes, etc.)
+// done within the measured window.
+func (b *B) SetOps(n int) {
+	b.result.Ops = n
+}
+
+// ReportMetric adds a metric to the result of the run.
+func (b *B) ReportMetric(name string, value float64, unit string) {
+	b.result.Metrics = append(b.result.Metrics, Metric{Name: name, Value: value, Unit: unit})
+}
+
+// ReportDuration adds a metric measured as a duration to the result of the run.
+func (b *B) ReportDuration(name string, d time.Duration) {
+	b.ReportMetric(name, float64(d.Nanoseconds()), UnitNanoseconds)
+}
+
+// ReportLatency adds the latency percentiles of the histogram to the result
+// of the run.
+func (b *B) ReportLatency(name string, h *Histogram) {
+	b.ReportDuration(name+" mean", h.Mean())
+	b.ReportDuration(name+" p50", h.Quantile(0.5))
+	b.ReportDuration(name+" p90", h.Quantile(0.9))
+	b.ReportDuration(name+" p99", h.Quantile(0.99))
+	b.ReportDuration(name+" p99.9", h.Quantile(0.999))
+	b.ReportDuration(name+" max", h.Max())
+}
+
+func (b *B) stopNodes() {
+	// in reverse order, so the nodes started first (usually the ones
+	// driving the benchmark) go down last
+	for i := len(b.nodes) - 1; i >= 0; i-- {
+		b.nodes[i].Stop()
+	}
+	b.nodes = nil
+}
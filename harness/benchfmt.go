@@ -0,0 +1,180 @@
+package harness
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// BenchmarkName returns the name of the result in the format of Go benchmarks,
+// e.g. "BenchmarkPingLocal11-8" for the "ping/local-11" scenario run with
+// GOMAXPROCS=8. The parameters that differ from the scenario defaults become
+// sub-benchmark keys, e.g. "BenchmarkPingLocal11/messages=1000-8", so benchstat
+// keeps the runs with different parameters apart.
+func BenchmarkName(r Result) string {
+	var name strings.Builder
+
+	name.WriteString("Benchmark")
+	upper := true
+	for _, c := range r.Scenario {
+		if unicode.IsLetter(c) == false && unicode.IsDigit(c) == false {
+			upper = true
+			continue
+		}
+		if upper {
+			c = unicode.ToUpper(c)
+			upper = false
+		}
+		name.WriteRune(c)
+	}
+
+	changed := r.ChangedParams()
+	for _, param := range changed.Names() {
+		value := strings.Map(func(c rune) rune {
+			if unicode.IsSpace(c) || c == '/' {
+				return '_'
+			}
+			return c
+		}, FormatParam(changed[param]))
+		fmt.Fprintf(&name, "/%s=%s", param, value)
+	}
+
+	if r.Env.GOMAXPROCS > 1 {
+		fmt.Fprintf(&name, "-%d", r.Env.GOMAXPROCS)
+	}
+	return name.String()
+}
+
+// WriteBench writes the results in the Go benchmark format that can be
+// processed by benchstat.
+func WriteBench(w io.Writer, results []Result) error {
+	env := CurrentEnvironment()
+	if len(results) > 0 {
+		env = results[0].Env
+	}
+	if err := WriteBenchHeader(w, env); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if err := WriteBenchResult(w, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteBenchHeader writes the configuration lines that precede the benchmark
+// results in the output of "go test -bench". The rest of the environment is
+// added as the extra configuration keys benchstat understands.
+func WriteBenchHeader(w io.Writer, env Environment) error {
+	var header strings.Builder
+	fmt.Fprintf(&header, "goos: %s\ngoarch: %s\npkg: ergobench\ncpu: %s\n",
+		runtime.GOOS, runtime.GOARCH, env.CPU)
+	for _, kv := range [][2]string{
+		{"go", env.GoVersion},
+		{"gogc", env.GOGC},
+		{"gomemlimit", env.GOMEMLIMIT},
+		{"kernel", env.Kernel},
+		{"cpu-governor", env.CPUGovernor},
+		{"ergo", env.ErgoVersion},
+		{"commit", env.Commit},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(&header, "%s: %s\n", kv[0], kv[1])
+		}
+	}
+	_, err := io.WriteString(w, header.String())
+	return err
+}
+
+// WriteBenchResult writes the result as a single benchmark line. The number of
+// operations done within the measured window is used as the iteration count.
+func WriteBenchResult(w io.Writer, r Result) error {
+	ops := r.Ops
+	if ops < 1 {
+		ops = 1
+	}
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "%s\t%d\t%.2f ns/op", BenchmarkName(r), ops, float64(r.Elapsed.Nanoseconds())/float64(ops))
+
+	units := make(map[string]int)
+	for _, m := range r.Metrics {
+		units[m.Unit]++
+	}
+	for _, m := range r.Metrics {
+		fmt.Fprintf(&line, "\t%.2f %s", m.Value, benchUnit(m, units[m.Unit] > 1))
+	}
+
+	_, err := fmt.Fprintln(w, line.String())
+	return err
+}
+
+// benchUnit returns the unit of the metric as shown in the benchmark line.
+// The Go benchmark format identifies a metric by its unit only, so the name of
+// the metric is added unless the unit is a rate ("msg/sec") unique within the
+// result.
+func benchUnit(m Metric, shared bool) string {
+	if strings.Contains(m.Unit, "/") && shared == false {
+		return m.Unit
+	}
+	name := strings.Map(func(c rune) rune {
+		if unicode.IsSpace(c) {
+			return '-'
+		}
+		return c
+	}, m.Name)
+	return name + "-" + m.Unit
+}
+
+// BenchLine is a benchmark result line in the output of "go test -bench".
+type BenchLine struct {
+	// Name is the benchmark name without the GOMAXPROCS suffix
+	Name       string
+	Iterations int
+	// Values maps the units (ns/op, B/op, allocs/op, ...) to the values
+	Values map[string]float64
+}
+
+// ParseBench reads the benchmark lines from the output of "go test -bench".
+// All the other lines are skipped.
+func ParseBench(r io.Reader) ([]BenchLine, error) {
+	var lines []BenchLine
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "Benchmark") == false {
+			continue
+		}
+		iterations, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		line := BenchLine{
+			Name:       fields[0],
+			Iterations: iterations,
+			Values:     make(map[string]float64),
+		}
+		if i := strings.LastIndexByte(line.Name, '-'); i > 0 {
+			if _, err := strconv.Atoi(line.Name[i+1:]); err == nil {
+				line.Name = line.Name[:i]
+			}
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			line.Values[fields[i+1]] = value
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
@@ -0,0 +1,411 @@
+package harness
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// The charts are rendered to SVG by hand, so they can be regenerated anywhere
+// without any dependencies. They are deliberately plain: a title, the axes
+// with the ticks, the data and the legend.
+
+const (
+	chartWidth  = 960
+	chartMargin = 70
+	chartLegend = 24
+)
+
+var chartPalette = []string{
+	"#4e79a7", "#f28e2b", "#59a14f", "#e15759",
+	"#76b7b2", "#edc948", "#b07aa1", "#9c755f",
+}
+
+func chartColor(i int) string {
+	return chartPalette[i%len(chartPalette)]
+}
+
+// Series is a named list of values, one per category of a bar chart. NaN
+// stands for a missing value.
+type Series struct {
+	Name   string
+	Values []float64
+	// Errors are drawn as error bars (e.g. the confidence interval), optional
+	Errors []float64
+}
+
+// BarChart draws the groups of bars, one group per category and one bar per
+// series in every group.
+type BarChart struct {
+	Title      string
+	Unit       string
+	Categories []string
+	Series     []Series
+}
+
+// WriteSVG renders the chart.
+func (c BarChart) WriteSVG(w io.Writer) error {
+	const (
+		groupHeight = 26
+		barGap      = 10
+		labelWidth  = 260
+	)
+	bars := len(c.Series)
+	if bars == 0 {
+		bars = 1
+	}
+	plotHeight := len(c.Categories) * (bars*groupHeight + barGap)
+	height := plotHeight + 2*chartMargin + chartLegend*len(c.Series)
+
+	max := 0.0
+	for _, s := range c.Series {
+		for i, v := range s.Values {
+			if math.IsNaN(v) {
+				continue
+			}
+			if i < len(s.Errors) {
+				v += s.Errors[i]
+			}
+			max = math.Max(max, v)
+		}
+	}
+	ticks := chartTicks(0, max)
+	scale := ticks[len(ticks)-1]
+	if scale == 0 {
+		scale = 1
+	}
+
+	x0 := float64(labelWidth)
+	plotWidth := float64(chartWidth-chartMargin) - x0
+	y0 := float64(chartMargin)
+
+	svg := newSVG(w, chartWidth, height)
+	svg.title(c.Title)
+
+	for _, t := range ticks {
+		x := x0 + t/scale*plotWidth
+		svg.line(x, y0, x, y0+float64(plotHeight), "#ddd")
+		svg.text(x, y0+float64(plotHeight)+16, "middle", 11, formatValue(t, c.Unit))
+	}
+
+	for i, category := range c.Categories {
+		top := y0 + float64(i*(bars*groupHeight+barGap))
+		svg.text(x0-8, top+float64(bars*groupHeight)/2+4, "end", 12, category)
+		for j, s := range c.Series {
+			if i >= len(s.Values) || math.IsNaN(s.Values[i]) {
+				// no value for the category
+				continue
+			}
+			v := s.Values[i]
+			y := top + float64(j*groupHeight)
+			width := v / scale * plotWidth
+			svg.rect(x0, y+2, width, groupHeight-4, chartColor(j))
+			if i < len(s.Errors) && s.Errors[i] > 0 {
+				e := s.Errors[i] / scale * plotWidth
+				middle := y + groupHeight/2
+				svg.line(x0+width-e, middle, x0+width+e, middle, "#333")
+				svg.line(x0+width-e, middle-5, x0+width-e, middle+5, "#333")
+				svg.line(x0+width+e, middle-5, x0+width+e, middle+5, "#333")
+			}
+			svg.text(x0+width+6, y+groupHeight/2+4, "start", 11, formatValue(v, c.Unit))
+		}
+	}
+	svg.line(x0, y0, x0, y0+float64(plotHeight), "#333")
+
+	names := make([]string, len(c.Series))
+	for i, s := range c.Series {
+		names[i] = s.Name
+	}
+	svg.legend(x0, y0+float64(plotHeight)+36, names)
+	return svg.close()
+}
+
+// Point is a point of a line chart.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Line is a named series of points of a line chart.
+type Line struct {
+	Name   string
+	Points []Point
+}
+
+// LineChart draws the lines over the numeric X axis.
+type LineChart struct {
+	Title  string
+	XLabel string
+	YUnit  string
+	Lines  []Line
+}
+
+// WriteSVG renders the chart.
+func (c LineChart) WriteSVG(w io.Writer) error {
+	const plotHeight = 420
+	height := plotHeight + 2*chartMargin + chartLegend*len(c.Lines) + 20
+
+	minX, maxX, maxY := math.Inf(1), math.Inf(-1), 0.0
+	for _, l := range c.Lines {
+		for _, p := range l.Points {
+			minX = math.Min(minX, p.X)
+			maxX = math.Max(maxX, p.X)
+			maxY = math.Max(maxY, p.Y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		minX, maxX = 0, 1
+	}
+	if minX == maxX {
+		minX, maxX = minX-1, maxX+1
+	}
+	xticks := chartTicks(minX, maxX)
+	yticks := chartTicks(0, maxY)
+	minX, maxX = xticks[0], xticks[len(xticks)-1]
+	scaleY := yticks[len(yticks)-1]
+	if scaleY == 0 {
+		scaleY = 1
+	}
+
+	x0 := float64(chartMargin + 30)
+	y0 := float64(chartMargin)
+	plotWidth := float64(chartWidth-chartMargin) - x0
+	px := func(x float64) float64 { return x0 + (x-minX)/(maxX-minX)*plotWidth }
+	py := func(y float64) float64 { return y0 + plotHeight - y/scaleY*plotHeight }
+
+	svg := newSVG(w, chartWidth, height)
+	svg.title(c.Title)
+
+	for _, t := range yticks {
+		svg.line(x0, py(t), x0+plotWidth, py(t), "#ddd")
+		svg.text(x0-8, py(t)+4, "end", 11, formatValue(t, c.YUnit))
+	}
+	for _, t := range xticks {
+		svg.line(px(t), y0+plotHeight, px(t), y0+plotHeight+5, "#333")
+		svg.text(px(t), y0+plotHeight+18, "middle", 11, formatValue(t, ""))
+	}
+	svg.line(x0, y0+plotHeight, x0+plotWidth, y0+plotHeight, "#333")
+	svg.line(x0, y0, x0, y0+plotHeight, "#333")
+	svg.text(x0+plotWidth/2, y0+plotHeight+38, "middle", 12, c.XLabel)
+
+	names := make([]string, len(c.Lines))
+	for i, l := range c.Lines {
+		names[i] = l.Name
+		points := make([][2]float64, len(l.Points))
+		for j, p := range l.Points {
+			points[j] = [2]float64{px(p.X), py(p.Y)}
+		}
+		svg.polyline(points, chartColor(i))
+	}
+	svg.legend(x0, y0+plotHeight+58, names)
+	return svg.close()
+}
+
+// Span is a named interval on a timeline.
+type Span struct {
+	Name  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// TimelineRow is a row of spans of a timeline.
+type TimelineRow struct {
+	Name  string
+	Spans []Span
+}
+
+// Timeline draws the spans of every row over the time axis. The spans with
+// the same name share the color.
+type Timeline struct {
+	Title string
+	Rows  []TimelineRow
+}
+
+// WriteSVG renders the chart.
+func (c Timeline) WriteSVG(w io.Writer) error {
+	const (
+		spanHeight = 18
+		rowGap     = 14
+		labelWidth = 260
+	)
+
+	var names []string
+	colors := make(map[string]string)
+	max := time.Duration(0)
+	rowHeight := 0
+	for _, r := range c.Rows {
+		if len(r.Spans)*spanHeight > rowHeight {
+			rowHeight = len(r.Spans) * spanHeight
+		}
+		for _, s := range r.Spans {
+			if _, found := colors[s.Name]; found == false {
+				colors[s.Name] = chartColor(len(names))
+				names = append(names, s.Name)
+			}
+			if s.End > max {
+				max = s.End
+			}
+		}
+	}
+	plotHeight := len(c.Rows) * (rowHeight + rowGap)
+	height := plotHeight + 2*chartMargin + chartLegend*len(names)
+
+	ticks := chartTicks(0, float64(max))
+	scale := ticks[len(ticks)-1]
+	if scale == 0 {
+		scale = 1
+	}
+
+	x0 := float64(labelWidth)
+	y0 := float64(chartMargin)
+	plotWidth := float64(chartWidth-chartMargin) - x0
+
+	svg := newSVG(w, chartWidth, height)
+	svg.title(c.Title)
+	for _, t := range ticks {
+		x := x0 + t/scale*plotWidth
+		svg.line(x, y0, x, y0+float64(plotHeight), "#ddd")
+		svg.text(x, y0+float64(plotHeight)+16, "middle", 11, formatValue(t, UnitNanoseconds))
+	}
+
+	for i, r := range c.Rows {
+		top := y0 + float64(i*(rowHeight+rowGap))
+		svg.text(x0-8, top+float64(rowHeight)/2+4, "end", 12, r.Name)
+		for j, s := range r.Spans {
+			x := x0 + float64(s.Start)/scale*plotWidth
+			width := math.Max(1, float64(s.End-s.Start)/scale*plotWidth)
+			svg.rect(x, top+float64(j*spanHeight)+1, width, spanHeight-2, colors[s.Name])
+		}
+	}
+	svg.line(x0, y0, x0, y0+float64(plotHeight), "#333")
+	svg.legend(x0, y0+float64(plotHeight)+36, names)
+	return svg.close()
+}
+
+// chartTicks returns about 5 round tick values covering [min, max].
+func chartTicks(min float64, max float64) []float64 {
+	if max <= min {
+		return []float64{min}
+	}
+	raw := (max - min) / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		step = m * magnitude
+		if step >= raw {
+			break
+		}
+	}
+
+	var ticks []float64
+	for t := math.Floor(min/step) * step; ; t += step {
+		ticks = append(ticks, t)
+		if t >= max {
+			break
+		}
+	}
+	return ticks
+}
+
+// formatValue formats the value with the unit in a short form (2.5M msg/sec)
+func formatValue(v float64, unit string) string {
+	if unit == UnitNanoseconds {
+		return time.Duration(v).Round(time.Duration(roundTo(v))).String()
+	}
+
+	s := ""
+	switch a := math.Abs(v); {
+	case a >= 1e9:
+		s = strconv.FormatFloat(v/1e9, 'g', 3, 64) + "G"
+	case a >= 1e6:
+		s = strconv.FormatFloat(v/1e6, 'g', 3, 64) + "M"
+	case a >= 1e3:
+		s = strconv.FormatFloat(v/1e3, 'g', 3, 64) + "K"
+	default:
+		s = strconv.FormatFloat(v, 'g', 3, 64)
+	}
+	if unit == "" {
+		return s
+	}
+	return s + " " + unit
+}
+
+// roundTo keeps 3 significant digits of a duration
+func roundTo(v float64) float64 {
+	if v < 1000 {
+		return 1
+	}
+	return math.Pow(10, math.Floor(math.Log10(v))-2)
+}
+
+// svgWriter writes the SVG elements and keeps the first error.
+type svgWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newSVG(w io.Writer, width int, height int) *svgWriter {
+	s := &svgWriter{w: bufio.NewWriter(w)}
+	s.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		width, height, width, height)
+	s.printf(`<rect width="100%%" height="100%%" fill="white"/>` + "\n")
+	return s
+}
+
+func (s *svgWriter) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+func (s *svgWriter) title(text string) {
+	s.text(chartWidth/2, 32, "middle", 16, text)
+}
+
+func (s *svgWriter) text(x float64, y float64, anchor string, size int, text string) {
+	s.printf(`<text x="%.1f" y="%.1f" text-anchor="%s" font-size="%d">%s</text>`+"\n",
+		x, y, anchor, size, html.EscapeString(text))
+}
+
+func (s *svgWriter) line(x1, y1, x2, y2 float64, color string) {
+	s.printf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", x1, y1, x2, y2, color)
+}
+
+func (s *svgWriter) rect(x, y, width, height float64, color string) {
+	s.printf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, width, height, color)
+}
+
+func (s *svgWriter) polyline(points [][2]float64, color string) {
+	s.printf(`<polyline fill="none" stroke="%s" stroke-width="2" points="`, color)
+	for _, p := range points {
+		s.printf("%.1f,%.1f ", p[0], p[1])
+	}
+	s.printf(`"/>` + "\n")
+	for _, p := range points {
+		s.printf(`<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`+"\n", p[0], p[1], color)
+	}
+}
+
+func (s *svgWriter) legend(x float64, y float64, names []string) {
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		top := y + float64(i*chartLegend)
+		s.rect(x, top, 14, 14, chartColor(i))
+		s.text(x+20, top+12, "start", 12, name)
+	}
+}
+
+func (s *svgWriter) close() error {
+	s.printf("</svg>\n")
+	if s.err != nil {
+		return s.err
+	}
+	return s.w.Flush()
+}
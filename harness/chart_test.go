@@ -0,0 +1,66 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestChartTicks(t *testing.T) {
+	ticks := chartTicks(0, 2_900_000)
+	expected := []float64{0, 1_000_000, 2_000_000, 3_000_000}
+	if len(ticks) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ticks)
+	}
+	for i := range ticks {
+		if ticks[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, ticks)
+		}
+	}
+}
+
+func TestChartSVG(t *testing.T) {
+	charts := []interface{ WriteSVG(io.Writer) error }{
+		BarChart{
+			Title:      "throughput <msg/sec>",
+			Unit:       "msg/sec",
+			Categories: []string{"ping/local-11", "ping/network-11"},
+			Series: []Series{
+				{Name: "a", Values: []float64{2.9e6, 1.2e6}, Errors: []float64{1e5, 0}},
+				{Name: "b", Values: []float64{math.NaN(), 1e6}},
+			},
+		},
+		LineChart{
+			Title: "memory",
+			YUnit: "KB",
+			Lines: []Line{{Name: "memusage", Points: []Point{{1e5, 2.9}, {1e6, 2.7}}}},
+		},
+		Timeline{
+			Title: "pubsub",
+			Rows: []TimelineRow{{Name: "pubsub/1M", Spans: []Span{
+				{Name: "subscribe", End: 2 * time.Second},
+				{Name: "deliver all", Start: 2 * time.Second, End: 3 * time.Second},
+			}}},
+		},
+	}
+
+	for _, c := range charts {
+		var buf bytes.Buffer
+		if err := c.WriteSVG(&buf); err != nil {
+			t.Fatal(err)
+		}
+		decoder := xml.NewDecoder(&buf)
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("invalid SVG of %T: %s", c, err)
+			}
+		}
+	}
+}
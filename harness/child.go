@@ -0,0 +1,172 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// childEnv is the environment variable telling the binary that it was started
+// by StartChild and which role it has to run.
+const childEnv = "ERGOBENCH_CHILD"
+
+// file descriptors of the control channel in the child process
+// (the ones following stdin, stdout and stderr)
+const (
+	childFdOut = 3
+	childFdIn  = 4
+)
+
+// Role is a part of a scenario run in a child process, e.g. a node the
+// scenario sends messages to over the network. It talks to the parent over
+// the control channel and returns once the parent closes it.
+type Role func(ctl *Control) error
+
+var (
+	roles     = make(map[string]Role)
+	rolesLock sync.RWMutex
+)
+
+// RegisterRole makes the role available to StartChild by its name. It is
+// supposed to be called from the init function of the package declaring
+// the scenario.
+func RegisterRole(name string, role Role) {
+	rolesLock.Lock()
+	defer rolesLock.Unlock()
+
+	if _, exist := roles[name]; exist {
+		panic(fmt.Sprintf("harness: role %q is already registered", name))
+	}
+	roles[name] = role
+}
+
+// IsChild reports whether the process was started by StartChild. The main
+// function must call RunChild instead of doing its job in this case.
+func IsChild() bool {
+	_, found := os.LookupEnv(childEnv)
+	return found
+}
+
+// RunChild runs the role the child process was started for.
+func RunChild() error {
+	name := os.Getenv(childEnv)
+	rolesLock.RLock()
+	role, found := roles[name]
+	rolesLock.RUnlock()
+	if found == false {
+		return fmt.Errorf("unknown role %q", name)
+	}
+
+	out := os.NewFile(childFdOut, "control-out")
+	in := os.NewFile(childFdIn, "control-in")
+	if out == nil || in == nil {
+		return fmt.Errorf("role %q: no control channel", name)
+	}
+	defer out.Close()
+	defer in.Close()
+
+	return role(newControl(in, out))
+}
+
+// Control is the channel between the scenario and its child process. The
+// messages are JSON values, so any value encoding/json can handle can be
+// passed over it.
+type Control struct {
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+func newControl(in *os.File, out *os.File) *Control {
+	return &Control{
+		enc: json.NewEncoder(out),
+		dec: json.NewDecoder(in),
+	}
+}
+
+// Send sends the message to the other side.
+func (c *Control) Send(message any) error {
+	return c.enc.Encode(message)
+}
+
+// Receive waits for the message from the other side and decodes it into
+// the value pointed to by message. It returns io.EOF once the other side
+// closes the channel (or exits).
+func (c *Control) Receive(message any) error {
+	if message == nil {
+		var skip json.RawMessage
+		return c.dec.Decode(&skip)
+	}
+	return c.dec.Decode(message)
+}
+
+type child struct {
+	cmd *exec.Cmd
+	in  *os.File
+	// closing the parent-to-child pipe tells the child to finish
+	out *os.File
+}
+
+// StartChild starts the binary once again as a child process running the
+// role. The child shares stdout and stderr of the parent and is stopped once
+// the scenario is finished. The returned Control is used to coordinate the
+// scenario with the child.
+func (b *B) StartChild(role string) (*Control, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// child-to-parent and parent-to-child pipes
+	pr, cw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cr, pw, err := os.Pipe()
+	if err != nil {
+		pr.Close()
+		cw.Close()
+		return nil, err
+	}
+
+	cmd := exec.Command(executable)
+	cmd.Env = append(os.Environ(), childEnv+"="+role)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{cw, cr} // become childFdOut and childFdIn
+	err = cmd.Start()
+
+	// the child has its own copies
+	cw.Close()
+	cr.Close()
+	if err != nil {
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+
+	b.children = append(b.children, child{cmd: cmd, in: pr, out: pw})
+	return newControl(pr, pw), nil
+}
+
+func (b *B) stopChildren() {
+	for _, c := range b.children {
+		c.out.Close()
+
+		done := make(chan struct{})
+		go func() {
+			c.cmd.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			c.cmd.Process.Kill()
+			<-done
+		}
+		c.in.Close()
+	}
+	b.children = nil
+}
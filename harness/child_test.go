@@ -0,0 +1,81 @@
+package harness
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	RegisterRole("test/echo", func(ctl *Control) error {
+		for {
+			var message string
+			if err := ctl.Receive(&message); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			if err := ctl.Send(message + "!"); err != nil {
+				return err
+			}
+		}
+	})
+
+	if IsChild() {
+		if err := RunChild(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestChild(t *testing.T) {
+	b := &B{}
+	ctl, err := b.StartChild("test/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, message := range []string{"ping", "pong"} {
+		if err := ctl.Send(message); err != nil {
+			t.Fatal(err)
+		}
+		var reply string
+		if err := ctl.Receive(&reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply != message+"!" {
+			t.Fatalf("expected %q, got %q", message+"!", reply)
+		}
+	}
+
+	cmd := b.children[0].cmd
+	b.stopChildren()
+	if cmd.ProcessState == nil || cmd.ProcessState.Success() == false {
+		t.Fatalf("child process did not exit cleanly: %v", cmd.ProcessState)
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	list, err := parseCPUList("0-3,8, 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{0, 1, 2, 3, 8, 10}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, list)
+	}
+	for i := range list {
+		if list[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, list)
+		}
+	}
+
+	for _, invalid := range []string{"", "a", "3-1", "-1"} {
+		if _, err := parseCPUList(invalid); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
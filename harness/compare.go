@@ -0,0 +1,268 @@
+package harness
+
+import (
+	"math"
+	"strings"
+)
+
+// Thresholds set how much a metric may get worse (in percent of the baseline
+// mean) before it's taken for a regression.
+type Thresholds struct {
+	// Throughput applies to the rates (units "*/sec"), they must not drop.
+	// The growth rates (units "+*/sec") must not rise by it.
+	Throughput float64
+	// Latency applies to the durations (unit "ns"), they must not rise
+	Latency float64
+	// Memory applies to the sizes (units "B", "KB", "MB", "GB"), they must not rise
+	Memory float64
+	// Alpha is the significance level. The change is taken for a regression
+	// only if the p-value of the t-test is below it. It's ignored if there
+	// are not enough iterations to run the test.
+	Alpha float64
+}
+
+// DefaultThresholds are used by the compare command unless set otherwise.
+var DefaultThresholds = Thresholds{
+	Throughput: 5,
+	Latency:    10,
+	Memory:     10,
+	Alpha:      0.05,
+}
+
+// Comparison is the change of a metric between the baseline and the current
+// results of a scenario.
+type Comparison struct {
+	Scenario string
+	Params   string
+	Base     Summary
+	Current  Summary
+	// Delta is the relative change of the mean in percent
+	Delta float64
+	// P is the p-value of Welch's t-test, NaN if there are not enough iterations
+	P float64
+	// Regression is true if the metric got worse beyond the threshold
+	Regression bool
+}
+
+// Compare matches the results by the scenario and its parameters and compares
+// the summaries of every metric. The scenarios and metrics missing on either
+// side are skipped.
+func Compare(base []Result, current []Result, thresholds Thresholds) []Comparison {
+	var comparisons []Comparison
+
+	baseGroups := groupResults(base)
+	currentGroups := groupResults(current)
+	for _, key := range groupKeys(current) {
+		cur := currentGroups[key]
+		old, found := baseGroups[key]
+		if found == false {
+			continue
+		}
+
+		baseSummaries := make(map[string]Summary)
+		baseValues := metricValues(old)
+		for _, s := range Summarize(old) {
+			baseSummaries[s.Name] = s
+		}
+		currentValues := metricValues(cur)
+
+		for _, s := range Summarize(cur) {
+			b, found := baseSummaries[s.Name]
+			if found == false || b.Unit != s.Unit {
+				continue
+			}
+			c := Comparison{
+				Scenario: cur[0].Scenario,
+				Params:   cur[0].Params.String(),
+				Base:     b,
+				Current:  s,
+				Delta:    math.NaN(),
+				P:        welch(baseValues[s.Name], currentValues[s.Name]),
+			}
+			if b.Mean != 0 {
+				c.Delta = 100 * (s.Mean - b.Mean) / math.Abs(b.Mean)
+			}
+			c.Regression = c.isRegression(thresholds)
+			comparisons = append(comparisons, c)
+		}
+	}
+
+	return comparisons
+}
+
+func (c Comparison) isRegression(t Thresholds) bool {
+	if math.IsNaN(c.Delta) {
+		return false
+	}
+	if math.IsNaN(c.P) == false && c.P >= t.Alpha {
+		// not significant
+		return false
+	}
+
+	switch {
+	case isGrowthUnit(c.Current.Unit):
+		return c.Delta > t.Throughput
+	case strings.HasSuffix(c.Current.Unit, "/sec"):
+		return -c.Delta > t.Throughput
+	case c.Current.Unit == UnitNanoseconds:
+		return c.Delta > t.Latency
+	case isMemoryUnit(c.Current.Unit):
+		return c.Delta > t.Memory
+	}
+	return false
+}
+
+// isGrowthUnit tells the rate something piles up at, a leak or a backlog
+// (e.g. "+KB/sec"). Unlike the throughput, lower is better.
+func isGrowthUnit(unit string) bool {
+	return strings.HasPrefix(unit, "+") && strings.HasSuffix(unit, "/sec")
+}
+
+func isMemoryUnit(unit string) bool {
+	switch unit {
+	case "B", "KB", "MB", "GB":
+		return true
+	}
+	return false
+}
+
+// groupResults groups the iterations of the same scenario run with the same
+// parameters.
+func groupResults(results []Result) map[string][]Result {
+	groups := make(map[string][]Result)
+	for _, r := range results {
+		key := resultKey(r)
+		groups[key] = append(groups[key], r)
+	}
+	return groups
+}
+
+// groupKeys returns the group keys in the order of the results
+func groupKeys(results []Result) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		key := resultKey(r)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func resultKey(r Result) string {
+	return r.Scenario + " " + r.Params.String()
+}
+
+func metricValues(results []Result) map[string][]float64 {
+	values := make(map[string][]float64)
+	for _, r := range results {
+		values["elapsed"] = append(values["elapsed"], float64(r.Elapsed))
+		for _, m := range r.Metrics {
+			values[m.Name] = append(values[m.Name], m.Value)
+		}
+	}
+	return values
+}
+
+// welch returns the two-sided p-value of Welch's t-test for the means of
+// the samples. It returns NaN if any of them has less than 2 values.
+func welch(a []float64, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return math.NaN()
+	}
+	sa := summarize("", "", a)
+	sb := summarize("", "", b)
+
+	va := sa.StdDev * sa.StdDev / float64(len(a))
+	vb := sb.StdDev * sb.StdDev / float64(len(b))
+	if va+vb == 0 {
+		if sa.Mean == sb.Mean {
+			return 1
+		}
+		return 0
+	}
+
+	t := (sa.Mean - sb.Mean) / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) /
+		(va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+
+	// P(|T| > t) for the Student's t-distribution with df degrees of freedom
+	return incompleteBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// incompleteBeta returns the regularized incomplete beta function I_x(a, b).
+func incompleteBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lbeta, _ := math.Lgamma(a + b)
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	front := math.Exp(lbeta - la - lb + a*math.Log(x) + b*math.Log(1-x))
+
+	// the continued fraction converges quickly for x < (a+1)/(a+b+2),
+	// use the symmetry otherwise
+	if x < (a+1)/(a+b+2) {
+		return front * betaFraction(a, b, x) / a
+	}
+	return 1 - front*betaFraction(b, a, 1-x)/b
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta
+// function by the modified Lentz's method.
+func betaFraction(a float64, b float64, x float64) float64 {
+	const (
+		iterations = 200
+		epsilon    = 1e-14
+		tiny       = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= iterations; m++ {
+		fm := float64(m)
+		// even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
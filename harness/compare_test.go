@@ -0,0 +1,122 @@
+package harness
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWelch(t *testing.T) {
+	// t = -2, df = 8
+	p := welch([]float64{1, 2, 3, 4, 5}, []float64{3, 4, 5, 6, 7})
+	if math.Abs(p-0.0805) > 0.0005 {
+		t.Fatalf("unexpected p-value: %v", p)
+	}
+	if p := welch([]float64{1}, []float64{1, 2}); math.IsNaN(p) == false {
+		t.Fatalf("expected NaN for a single value, got %v", p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	results := func(throughput []float64, latency []float64) []Result {
+		var rs []Result
+		for i := range throughput {
+			rs = append(rs, Result{
+				Scenario: "ping/local-11",
+				Params:   Params{"messages": 1000},
+				Elapsed:  1e9,
+				Metrics: []Metric{
+					{Name: "throughput", Value: throughput[i], Unit: "msg/sec"},
+					{Name: "latency p99", Value: latency[i], Unit: UnitNanoseconds},
+				},
+			})
+		}
+		return rs
+	}
+	base := results([]float64{100, 101, 99, 100, 100}, []float64{1000, 1010, 990, 1000, 1000})
+	current := results([]float64{80, 81, 79, 80, 80}, []float64{1001, 1011, 991, 1001, 1001})
+	other := Result{Scenario: "memusage", Params: Params{}}
+
+	comparisons := Compare(base, append(current, other), DefaultThresholds)
+	if len(comparisons) != 3 {
+		t.Fatalf("expected 3 comparisons, got %d", len(comparisons))
+	}
+	for _, c := range comparisons {
+		switch c.Current.Name {
+		case "throughput":
+			if c.Regression == false || math.Abs(c.Delta+20) > 1e-9 {
+				t.Fatalf("expected throughput regression: %+v", c)
+			}
+		default:
+			if c.Regression {
+				t.Fatalf("unexpected regression: %+v", c)
+			}
+		}
+	}
+}
+
+func TestCompareGrowth(t *testing.T) {
+	results := func(slope ...float64) []Result {
+		var rs []Result
+		for _, v := range slope {
+			rs = append(rs, Result{
+				Scenario: "lifecycle/churn",
+				Params:   Params{},
+				Elapsed:  1e9,
+				Metrics:  []Metric{{Name: "memory slope", Value: v, Unit: "+KB/sec"}},
+			})
+		}
+		return rs
+	}
+	base := results(10, 11, 9, 10, 10)
+
+	for _, s := range []struct {
+		current    []Result
+		regression bool
+	}{
+		{results(20, 21, 19, 20, 20), true}, // leaks faster
+		{results(1, 2, 0, 1, 1), false},     // leaks slower
+	} {
+		found := false
+		for _, c := range Compare(base, s.current, DefaultThresholds) {
+			if c.Current.Name != "memory slope" {
+				continue
+			}
+			found = true
+			if c.Regression != s.regression {
+				t.Fatalf("expected regression %v: %+v", s.regression, c)
+			}
+		}
+		if found == false {
+			t.Fatalf("memory slope is not compared")
+		}
+	}
+}
+
+func TestCompareReadResults(t *testing.T) {
+	current := []Result{{
+		Scenario: "ping/local-11",
+		Params:   Params{"messages": 3_000_000, "pings": 1, "duration": time.Duration(0), "rate": 0.5},
+		Elapsed:  1e9,
+		Metrics:  []Metric{{Name: "throughput", Value: 100, Unit: "msg/sec"}},
+	}}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := WriteResults(path, "json", current); err != nil {
+		t.Fatal(err)
+	}
+	base, err := ReadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k1, k2 := resultKey(base[0]), resultKey(current[0]); k1 != k2 {
+		t.Fatalf("keys differ: %q (JSON) vs %q", k1, k2)
+	}
+	if comparisons := Compare(base, current, DefaultThresholds); len(comparisons) != 2 {
+		t.Fatalf("expected 2 comparisons, got %d", len(comparisons))
+	}
+	if n1, n2 := BenchmarkName(base[0]), BenchmarkName(current[0]); n1 != n2 {
+		t.Fatalf("benchmark names differ: %q (JSON) vs %q", n1, n2)
+	}
+}
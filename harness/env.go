@@ -0,0 +1,198 @@
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/klauspost/cpuid/v2"
+)
+
+// Environment describes the machine and the runtime the scenario was run on.
+type Environment struct {
+	GoVersion     string `json:"go_version"`
+	CPU           string `json:"cpu"`
+	PhysicalCores int    `json:"physical_cores"`
+	NumCPU        int    `json:"num_cpu"`
+	GOMAXPROCS    int    `json:"gomaxprocs"`
+	// GOGC is the GC percent or "off"
+	GOGC string `json:"gogc"`
+	// GOMEMLIMIT is the soft memory limit in bytes or "off"
+	GOMEMLIMIT string `json:"gomemlimit"`
+	Kernel     string `json:"kernel"`
+	// CPUGovernor is the frequency governor of the CPUs (the different ones
+	// are comma separated)
+	CPUGovernor string `json:"cpu_governor"`
+	// CgroupCPUs is the CPU quota of the cgroup, 0 if there is no limit
+	CgroupCPUs float64 `json:"cgroup_cpus"`
+	// CgroupMemory is the memory limit of the cgroup in bytes, 0 if there is
+	// no limit
+	CgroupMemory int64 `json:"cgroup_memory"`
+	// ErgoVersion is the version of the ergo.services/ergo module
+	ErgoVersion string `json:"ergo_version"`
+	// Commit is the git commit of the benchmarks, with the "-dirty" suffix
+	// if there are uncommitted changes
+	Commit string `json:"commit"`
+}
+
+var (
+	// the parts of the environment that don't change while running
+	staticEnv     Environment
+	staticEnvOnce sync.Once
+)
+
+// CurrentEnvironment returns the environment of the running process.
+func CurrentEnvironment() Environment {
+	staticEnvOnce.Do(func() {
+		staticEnv = Environment{
+			GoVersion:     runtime.Version(),
+			CPU:           CPU.BrandName,
+			PhysicalCores: CPU.PhysicalCores,
+			NumCPU:        runtime.NumCPU(),
+			Kernel:        kernelVersion(),
+			CPUGovernor:   cpuGovernor(),
+			ErgoVersion:   ergoVersion(),
+			Commit:        gitCommit(),
+		}
+		staticEnv.CgroupCPUs, staticEnv.CgroupMemory = cgroupLimits()
+	})
+
+	env := staticEnv
+	env.GOMAXPROCS = runtime.GOMAXPROCS(0)
+
+	// there is no getter for the GC percent
+	gogc := debug.SetGCPercent(100)
+	debug.SetGCPercent(gogc)
+	env.GOGC = "off"
+	if gogc >= 0 {
+		env.GOGC = strconv.Itoa(gogc)
+	}
+	env.GOMEMLIMIT = "off"
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		env.GOMEMLIMIT = strconv.FormatInt(limit, 10)
+	}
+	return env
+}
+
+// ergoVersion returns the version of the ergo module the binary is built with.
+func ergoVersion() string {
+	info, found := debug.ReadBuildInfo()
+	if found == false {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != "ergo.services/ergo" {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Path + " " + dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
+
+// gitCommit returns the commit from the build info ("go build" stamps it) or
+// asks git if it's not there ("go run" doesn't).
+func gitCommit() string {
+	if info, found := debug.ReadBuildInfo(); found {
+		revision, modified := "", false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if revision != "" {
+			if modified {
+				revision += "-dirty"
+			}
+			return revision
+		}
+	}
+
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	revision := strings.TrimSpace(string(out))
+	status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output()
+	if err == nil && len(bytes.TrimSpace(status)) > 0 {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// envColumns are the CSV columns of the environment in the order of values()
+var envColumns = []string{
+	"go_version", "cpu", "physical_cores", "num_cpu", "gomaxprocs",
+	"gogc", "gomemlimit", "kernel", "cpu_governor", "cgroup_cpus", "cgroup_memory",
+	"ergo_version", "commit",
+}
+
+func (e Environment) values() []string {
+	return []string{
+		e.GoVersion,
+		e.CPU,
+		strconv.Itoa(e.PhysicalCores),
+		strconv.Itoa(e.NumCPU),
+		strconv.Itoa(e.GOMAXPROCS),
+		e.GOGC,
+		e.GOMEMLIMIT,
+		e.Kernel,
+		e.CPUGovernor,
+		strconv.FormatFloat(e.CgroupCPUs, 'f', -1, 64),
+		strconv.FormatInt(e.CgroupMemory, 10),
+		e.ErgoVersion,
+		e.Commit,
+	}
+}
+
+// Diff returns the fields that differ from the other environment, e.g.
+// "gomaxprocs: 8 -> 16".
+func (e Environment) Diff(other Environment) []string {
+	var diff []string
+	values, others := e.values(), other.values()
+	for i, column := range envColumns {
+		if values[i] != others[i] {
+			diff = append(diff, fmt.Sprintf("%s: %s -> %s", column, values[i], others[i]))
+		}
+	}
+	return diff
+}
+
+func printBanner(s Scenario, env Environment) {
+	fmt.Printf("=================================================================\n")
+	fmt.Printf("%s: %s\n", s.Name, s.Description)
+	fmt.Printf("=================================================================\n")
+	fmt.Printf("Go Version : %s\n", env.GoVersion)
+	fmt.Printf("CPU: %s (Physical Cores: %d)\n", env.CPU, env.PhysicalCores)
+	fmt.Printf("Runtime CPUs: %d (GOMAXPROCS: %d, GOGC: %s, GOMEMLIMIT: %s)\n",
+		env.NumCPU, env.GOMAXPROCS, env.GOGC, env.GOMEMLIMIT)
+	if env.Kernel != "" {
+		fmt.Printf("Kernel: %s (CPU governor: %s)\n", env.Kernel, orUnknown(env.CPUGovernor))
+	}
+	if env.CgroupCPUs > 0 {
+		fmt.Printf("Cgroup CPU limit: %g\n", env.CgroupCPUs)
+	}
+	if env.CgroupMemory > 0 {
+		fmt.Printf("Cgroup memory limit: %d bytes\n", env.CgroupMemory)
+	}
+	fmt.Printf("Ergo: %s (commit %s)\n", orUnknown(env.ErgoVersion), orUnknown(env.Commit))
+	fmt.Printf("\n")
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
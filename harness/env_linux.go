@@ -0,0 +1,93 @@
+//go:build linux
+
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func kernelVersion() string {
+	return readLine("/proc/sys/kernel/osrelease")
+}
+
+func cpuGovernor() string {
+	paths, _ := filepath.Glob("/sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_governor")
+	var governors []string
+	for _, path := range paths {
+		governor := readLine(path)
+		if governor != "" && contains(governors, governor) == false {
+			governors = append(governors, governor)
+		}
+	}
+	sort.Strings(governors)
+	return strings.Join(governors, ",")
+}
+
+// cgroupLimits returns the CPU quota and the memory limit of the cgroup of
+// the process (v2 or v1), zeros if there are no limits.
+func cgroupLimits() (float64, int64) {
+	// cgroup v2: the "0::/path" line
+	for _, line := range strings.Split(readFile("/proc/self/cgroup"), "\n") {
+		path, found := strings.CutPrefix(line, "0::")
+		if found == false {
+			continue
+		}
+		dir := filepath.Join("/sys/fs/cgroup", path)
+		if _, err := os.Stat(filepath.Join(dir, "cpu.max")); err != nil {
+			// the namespace root is mounted if the process is in a container
+			dir = "/sys/fs/cgroup"
+		}
+		return parseCPUMax(readLine(filepath.Join(dir, "cpu.max"))),
+			parseMemoryLimit(readLine(filepath.Join(dir, "memory.max")))
+	}
+
+	// cgroup v1
+	var cpus float64
+	quota, err1 := strconv.ParseFloat(readLine("/sys/fs/cgroup/cpu/cpu.cfs_quota_us"), 64)
+	period, err2 := strconv.ParseFloat(readLine("/sys/fs/cgroup/cpu/cpu.cfs_period_us"), 64)
+	if err1 == nil && err2 == nil && quota > 0 && period > 0 {
+		cpus = quota / period
+	}
+	return cpus, parseMemoryLimit(readLine("/sys/fs/cgroup/memory/memory.limit_in_bytes"))
+}
+
+// parseCPUMax parses the "$MAX $PERIOD" content of cpu.max, where $MAX is
+// "max" if there is no limit.
+func parseCPUMax(s string) float64 {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// parseMemoryLimit parses memory.max ("max" if there is no limit) or
+// memory.limit_in_bytes (a value close to MaxInt64 if there is no limit).
+func parseMemoryLimit(s string) int64 {
+	limit, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || limit >= 1<<62 {
+		return 0
+	}
+	return limit
+}
+
+func readFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func readLine(path string) string {
+	return strings.TrimSpace(readFile(path))
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package harness
+
+import (
+	"os/exec"
+	"strings"
+)
+
+func kernelVersion() string {
+	out, err := exec.Command("uname", "-r").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func cpuGovernor() string {
+	return ""
+}
+
+func cgroupLimits() (float64, int64) {
+	return 0, 0
+}
@@ -0,0 +1,172 @@
+// Package harness is shared by all benchmark scenarios. It takes care of the
+// node bootstrap, prints the environment banner, measures the benchmark window
+// and collects the results, so a scenario only describes what is benchmarked.
+package harness
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scenario describes a single benchmark.
+type Scenario struct {
+	// Name is a unique name of the scenario, e.g. "ping/local-11"
+	Name string
+	// Description is a one-line summary printed in the banner
+	Description string
+	// Params declares the parameters the scenario accepts
+	Params []Param
+	// Run performs the benchmark
+	Run func(b *B) error
+}
+
+var (
+	scenarios     = make(map[string]Scenario)
+	scenariosLock sync.RWMutex
+)
+
+// Register makes the scenario available by its name. It is supposed to be
+// called from the init function of the package declaring the scenario.
+func Register(s Scenario) {
+	if s.Name == "" || s.Run == nil {
+		panic("harness: scenario must have a name and a Run function")
+	}
+
+	scenariosLock.Lock()
+	defer scenariosLock.Unlock()
+
+	if _, exist := scenarios[s.Name]; exist {
+		panic(fmt.Sprintf("harness: scenario %q is already registered", s.Name))
+	}
+	scenarios[s.Name] = s
+}
+
+// Lookup returns the registered scenario with the given name.
+func Lookup(name string) (Scenario, bool) {
+	scenariosLock.RLock()
+	defer scenariosLock.RUnlock()
+	s, found := scenarios[name]
+	return s, found
+}
+
+// Names returns the names of all registered scenarios in sorted order.
+func Names() []string {
+	scenariosLock.RLock()
+	defer scenariosLock.RUnlock()
+
+	names := make([]string, 0, len(scenarios))
+	for name := range scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Match returns the registered scenarios selected by the pattern in sorted
+// order. The pattern is either a scenario name, a group of scenarios
+// (e.g. "ping" selects all "ping/..." scenarios), or a glob pattern in the
+// syntax of path.Match (e.g. "ping/local-*").
+func Match(pattern string) ([]Scenario, error) {
+	var matched []Scenario
+
+	for _, name := range Names() {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok == false && strings.HasPrefix(name, pattern+"/") == false {
+			continue
+		}
+		s, _ := Lookup(name)
+		matched = append(matched, s)
+	}
+
+	return matched, nil
+}
+
+// RunIterations runs the scenario warmup times without recording the results,
+// then count times more. The summary of the measured iterations is printed
+// if there are more than one.
+func RunIterations(s Scenario, params Params, warmup int, count int) ([]Result, error) {
+	env := CurrentEnvironment()
+	printBanner(s, env)
+
+	for i := 1; i <= warmup; i++ {
+		result, err := run(s, params, env, 0)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("WARM-UP %d/%d: %s, elapsed %s\n\n", i, warmup, s.Name, result.Elapsed)
+	}
+
+	var results []Result
+	for i := 1; i <= count; i++ {
+		result, err := run(s, params, env, i)
+		if err != nil {
+			return results, err
+		}
+		printResult(result)
+		results = append(results, result)
+	}
+
+	if count > 1 {
+		printSummary(s.Name, Summarize(results))
+	}
+	return results, nil
+}
+
+// RunOnce runs the registered scenario once with the parameters on top of its
+// defaults, without the warm-up.
+func RunOnce(name string, params Params) (Result, error) {
+	s, found := Lookup(name)
+	if found == false {
+		return Result{}, fmt.Errorf("unknown scenario %q", name)
+	}
+	results, err := RunIterations(s, params, 0, 1)
+	if err != nil {
+		return Result{}, err
+	}
+	return results[0], nil
+}
+
+// run runs the scenario once. The iteration is 0 for the warm-up runs,
+// they are not profiled.
+func run(s Scenario, params Params, env Environment, iteration int) (Result, error) {
+	b := &B{
+		result: Result{
+			Scenario:  s.Name,
+			Params:    s.DefaultParams(),
+			Iteration: iteration,
+			Env:       env,
+			Start:     time.Now(),
+		},
+	}
+	for name, value := range params {
+		b.result.Params[name] = value
+	}
+	if len(profiles.Kinds) > 0 && iteration > 0 {
+		b.profiler = newProfiler(profiles, b.result)
+	}
+
+	err := s.Run(b)
+	if b.profiler != nil {
+		// if the scenario failed within the measured window
+		b.profiler.stop()
+	}
+	b.stopNodes()
+	b.stopChildren()
+	b.restoreAffinity()
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if b.profiler != nil && b.profiler.err != nil {
+		return Result{}, fmt.Errorf("%s: profiling: %w", s.Name, b.profiler.err)
+	}
+
+	b.result.Elapsed = b.elapsed
+	return b.result, nil
+}
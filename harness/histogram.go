@@ -0,0 +1,153 @@
+package harness
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	// histogramSubBits defines the precision of the histogram. Each power of two
+	// range of values is split into 2^(histogramSubBits-1) buckets, which keeps
+	// the relative error of the recorded values under 1%.
+	histogramSubBits    = 8
+	histogramSubBuckets = 1 << histogramSubBits
+	histogramHalf       = histogramSubBuckets / 2
+	histogramBuckets    = histogramSubBuckets + (64-histogramSubBits)*histogramHalf
+)
+
+// Histogram records durations into log-linear buckets in the manner of
+// HdrHistogram: values below 256ns are recorded exactly, larger ones with
+// a relative error under 1%. It takes a fixed amount of memory regardless of
+// the number of the recorded values.
+//
+// Histogram is not safe for concurrent use. Each process keeps its own one
+// and they are merged once the measurement is done.
+type Histogram struct {
+	counts []uint64
+	total  uint64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+// NewHistogram creates an empty histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]uint64, histogramBuckets),
+		min:    math.MaxInt64,
+	}
+}
+
+// Record adds the duration to the histogram. Negative durations are recorded
+// as zero.
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[histogramIndex(uint64(v))]++
+	h.total++
+	h.sum += float64(v)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds all the values recorded by the other histogram.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.total == 0 {
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	h.sum += other.sum
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count returns the number of the recorded values.
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Min returns the smallest recorded value.
+func (h *Histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.min)
+}
+
+// Max returns the largest recorded value.
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Mean returns the arithmetic mean of the recorded values.
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.total))
+}
+
+// Quantile returns the value below which the given fraction (0..1) of the
+// recorded values falls, e.g. Quantile(0.99) is the 99th percentile.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.Min()
+	}
+	if q >= 1 {
+		return h.Max()
+	}
+
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen < rank {
+			continue
+		}
+		// the highest value of the bucket, but not above the recorded maximum
+		v := int64(histogramUpperBound(i))
+		if v > h.max {
+			v = h.max
+		}
+		if v < h.min {
+			v = h.min
+		}
+		return time.Duration(v)
+	}
+	return h.Max()
+}
+
+func histogramIndex(v uint64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histogramSubBits
+	top := v >> shift // in [histogramHalf, histogramSubBuckets)
+	return histogramSubBuckets + (shift-1)*histogramHalf + int(top-histogramHalf)
+}
+
+func histogramUpperBound(index int) uint64 {
+	if index < histogramSubBuckets {
+		return uint64(index)
+	}
+	shift := (index-histogramSubBuckets)/histogramHalf + 1
+	top := uint64((index-histogramSubBuckets)%histogramHalf + histogramHalf)
+	return (top+1)<<shift - 1
+}
@@ -0,0 +1,70 @@
+package harness
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHistogramIndex(t *testing.T) {
+	for _, v := range []uint64{0, 1, 255, 256, 257, 1000, 123456789, math.MaxInt64, math.MaxUint64} {
+		i := histogramIndex(v)
+		if i < 0 || i >= histogramBuckets {
+			t.Fatalf("index %d of %d is out of range", i, v)
+		}
+		if upper := histogramUpperBound(i); upper < v {
+			t.Fatalf("upper bound %d of the bucket is below the value %d", upper, v)
+		}
+		if i > 0 {
+			if lower := histogramUpperBound(i-1) + 1; lower > v {
+				t.Fatalf("lower bound %d of the bucket is above the value %d", lower, v)
+			}
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := NewHistogram()
+	values := make([]int, 100_000)
+	for i := range values {
+		values[i] = rand.Intn(10_000_000)
+		h.Record(time.Duration(values[i]))
+	}
+	sort.Ints(values)
+
+	for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+		expected := float64(values[int(math.Ceil(q*float64(len(values))))-1])
+		got := float64(h.Quantile(q))
+		if math.Abs(got-expected)/expected > 0.01 {
+			t.Fatalf("quantile %v: expected %v, got %v", q, expected, got)
+		}
+	}
+	if h.Max() != time.Duration(values[len(values)-1]) {
+		t.Fatalf("unexpected max: %v", h.Max())
+	}
+	if h.Quantile(1) != h.Max() {
+		t.Fatalf("quantile 1 must be equal to the max")
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	h1 := NewHistogram()
+	h2 := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h1.Record(time.Duration(i))
+		h2.Record(time.Duration(i + 100))
+	}
+	h1.Merge(h2)
+
+	if h1.Count() != 200 {
+		t.Fatalf("expected 200 values, got %d", h1.Count())
+	}
+	if h1.Min() != 1 || h1.Max() != 200 {
+		t.Fatalf("unexpected min/max: %v/%v", h1.Min(), h1.Max())
+	}
+	if q := h1.Quantile(0.5); q != 100 {
+		t.Fatalf("unexpected median: %v", q)
+	}
+}
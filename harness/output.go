@@ -0,0 +1,113 @@
+package harness
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Output formats of the results.
+const (
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatBench = "bench"
+)
+
+// WriteResults writes the results to the file in the given format.
+// If the format is empty, it is taken from the file extension.
+func WriteResults(path string, format string, results []Result) error {
+	if format == "" {
+		format = FormatJSON
+		if filepath.Ext(path) == ".csv" {
+			format = FormatCSV
+		}
+	}
+
+	var write func(io.Writer, []Result) error
+	switch format {
+	case FormatJSON:
+		write = WriteJSON
+	case FormatCSV:
+		write = WriteCSV
+	case FormatBench:
+		write = WriteBench
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := write(f, results); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// ReadResults reads the results written by WriteResults in the JSON format.
+func ReadResults(path string) ([]Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("%s: %w (only the JSON format can be read)", path, err)
+	}
+	return results, nil
+}
+
+// WriteJSON writes the results as a JSON array.
+func WriteJSON(w io.Writer, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// WriteCSV writes the results in CSV with a row per metric.
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"scenario", "params", "iteration", "start", "elapsed_ns", "ops"}
+	header = append(header, envColumns...)
+	header = append(header, "metric", "value", "unit")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		row := []string{
+			r.Scenario,
+			r.Params.String(),
+			strconv.Itoa(r.Iteration),
+			r.Start.Format("2006-01-02T15:04:05.000Z07:00"),
+			strconv.FormatInt(r.Elapsed.Nanoseconds(), 10),
+			strconv.Itoa(r.Ops),
+		}
+		row = append(row, r.Env.values()...)
+		for _, m := range r.Metrics {
+			record := append(row[:len(row):len(row)],
+				m.Name,
+				strconv.FormatFloat(m.Value, 'f', -1, 64),
+				m.Unit,
+			)
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
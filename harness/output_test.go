@@ -0,0 +1,113 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testResults() []Result {
+	return []Result{
+		{
+			Scenario: "ping/local-11",
+			Params:   Params{"n": 1000, "duration": 10 * time.Second},
+			Env:      Environment{GoVersion: "go1.21.6", CPU: "Test CPU", PhysicalCores: 4, NumCPU: 8},
+			Start:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Elapsed:  time.Second,
+			Metrics: []Metric{
+				{Name: "messages", Value: 1000, Unit: "msg"},
+				{Name: "throughput", Value: 1000.5, Unit: "msg/sec"},
+			},
+		},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(decoded))
+	}
+	params := decoded[0]["params"].(map[string]any)
+	if params["duration"] != "10s" {
+		t.Fatalf("expected duration param as text, got %v", params["duration"])
+	}
+	if decoded[0]["elapsed_ns"] != float64(time.Second) {
+		t.Fatalf("unexpected elapsed: %v", decoded[0]["elapsed_ns"])
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// header + a row per metric
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	last := records[2]
+	if last[0] != "ping/local-11" || last[1] != "duration=10s n=1000" {
+		t.Fatalf("unexpected row: %v", last)
+	}
+	if last[len(last)-3] != "throughput" || last[len(last)-2] != "1000.5" {
+		t.Fatalf("unexpected metric: %v", last)
+	}
+}
+
+func TestWriteBench(t *testing.T) {
+	results := testResults()
+	results[0].Ops = 1000
+	results[0].Env.GOMAXPROCS = 8
+	results[0].Metrics = append(results[0].Metrics,
+		Metric{Name: "memory used", Value: 10, Unit: "KB"},
+		Metric{Name: "memory allocated", Value: 5, Unit: "KB"},
+	)
+
+	var buf bytes.Buffer
+	if err := WriteBench(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "BenchmarkPingLocal11/duration=10s/n=1000-8\t1000\t1000000.00 ns/op" +
+		"\t1000.00 messages-msg\t1000.50 msg/sec\t10.00 memory-used-KB\t5.00 memory-allocated-KB\n"
+	if bytes.HasSuffix(buf.Bytes(), []byte(expected)) == false {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestEnvironmentDiff(t *testing.T) {
+	env := CurrentEnvironment()
+	if len(env.values()) != len(envColumns) {
+		t.Fatalf("expected %d values, got %d", len(envColumns), len(env.values()))
+	}
+	if env.GoVersion == "" || env.GOMAXPROCS < 1 || env.GOGC == "" || env.GOMEMLIMIT == "" {
+		t.Fatalf("incomplete environment: %+v", env)
+	}
+	if diff := env.Diff(env); len(diff) != 0 {
+		t.Fatalf("expected no difference, got %v", diff)
+	}
+
+	other := env
+	other.GOMAXPROCS = env.GOMAXPROCS + 1
+	other.Commit = "abc"
+	diff := env.Diff(other)
+	if len(diff) != 2 || strings.HasPrefix(diff[0], "gomaxprocs: ") == false || diff[1] != "commit: "+env.Commit+" -> abc" {
+		t.Fatalf("unexpected difference: %v", diff)
+	}
+}
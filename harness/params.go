@@ -0,0 +1,270 @@
+package harness
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables setting the scenario
+// parameters, e.g. ERGOBENCH_MESSAGES=1000 sets the "messages" parameter of
+// every scenario that has it.
+const EnvPrefix = "ERGOBENCH_"
+
+// Param declares a parameter of a scenario. It can be set on the command line
+// as a flag following the scenario name.
+type Param struct {
+	Name  string
+	Usage string
+	// Default defines the type of the parameter as well. Supported types are
+	// int, float64, string, bool and time.Duration.
+	Default any
+}
+
+// Type returns the name of the parameter type as shown in the usage.
+func (p Param) Type() string {
+	switch p.Default.(type) {
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case time.Duration:
+		return "duration"
+	}
+	return "string"
+}
+
+// Params holds the parameter values of a scenario run.
+type Params map[string]any
+
+// Names returns the parameter names in sorted order.
+func (p Params) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the parameters as a space separated list of name=value pairs.
+func (p Params) String() string {
+	pairs := make([]string, 0, len(p))
+	for _, name := range p.Names() {
+		pairs = append(pairs, name+"="+FormatParam(p[name]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// FormatParam returns the text form of the parameter value. The numbers read
+// from JSON are float64, so they are formatted without the exponent to match
+// the int values of the same run ("3000000", not "3e+06").
+func FormatParam(value any) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// ChangedParams returns the parameters of the result that differ from the
+// defaults of the scenario, all of them if the scenario isn't registered.
+func (r Result) ChangedParams() Params {
+	defaults := Params{}
+	if s, found := Lookup(r.Scenario); found {
+		defaults = s.DefaultParams()
+	}
+	changed := Params{}
+	for name, value := range r.Params {
+		if d, found := defaults[name]; found && FormatParam(d) == FormatParam(value) {
+			continue
+		}
+		changed[name] = value
+	}
+	return changed
+}
+
+// MarshalJSON encodes durations in their text form ("10s") rather than
+// as a number of nanoseconds.
+func (p Params) MarshalJSON() ([]byte, error) {
+	values := make(map[string]any, len(p))
+	for name, value := range p {
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		values[name] = value
+	}
+	return json.Marshal(values)
+}
+
+// DefaultParams returns the parameters of the scenario set to their defaults.
+func (s Scenario) DefaultParams() Params {
+	params := make(Params)
+	for _, p := range s.Params {
+		params[p.Name] = p.Default
+	}
+	return params
+}
+
+// Values holds the parameter values of a scenario set on the command line or
+// in the environment in their text form. A value can be a comma-separated
+// list, e.g. "-pings 1,2,4", to sweep the parameter: the scenario runs once
+// for every combination of the listed values.
+type Values map[string]string
+
+// FlagSet returns a flag set that stores the parsed flags into values.
+func (s Scenario) FlagSet(values Values) *flag.FlagSet {
+	fs := flag.NewFlagSet(s.Name, flag.ContinueOnError)
+	for _, p := range s.Params {
+		fs.Var(paramValue{param: p, values: values}, p.Name, p.Usage)
+	}
+	return fs
+}
+
+// SetFromEnv sets the parameters of the scenario from the environment variables.
+// The flags of the scenario take precedence, so it must be called before
+// parsing them.
+func (s Scenario) SetFromEnv(values Values) error {
+	for _, p := range s.Params {
+		name := EnvPrefix + strings.Map(func(c rune) rune {
+			if c >= 'a' && c <= 'z' {
+				return c - 'a' + 'A'
+			}
+			if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+				return c
+			}
+			return '_'
+		}, p.Name)
+
+		value, found := os.LookupEnv(name)
+		if found == false {
+			continue
+		}
+		if err := (paramValue{param: p, values: values}).Set(value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Expand returns the parameters of every run defined by the values. The
+// parameters not set in values keep their defaults. If the values list several
+// values for a parameter, the runs sweep them in the order of declaration of
+// the parameters: the first declared parameter changes the slowest.
+func (s Scenario) Expand(values Values) ([]Params, error) {
+	sweep := []Params{s.DefaultParams()}
+
+	for _, p := range s.Params {
+		text, found := values[p.Name]
+		if found == false {
+			continue
+		}
+
+		var next []Params
+		for _, params := range sweep {
+			for _, item := range strings.Split(text, ",") {
+				value, err := p.parse(item)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for %s: %w", item, p.Name, err)
+				}
+				run := make(Params, len(params))
+				for name, v := range params {
+					run[name] = v
+				}
+				run[p.Name] = value
+				next = append(next, run)
+			}
+		}
+		sweep = next
+	}
+
+	return sweep, nil
+}
+
+func (p Param) parse(s string) (any, error) {
+	s = strings.TrimSpace(s)
+	switch p.Default.(type) {
+	case int:
+		return strconv.Atoi(s)
+	case float64:
+		return strconv.ParseFloat(s, 64)
+	case bool:
+		return strconv.ParseBool(s)
+	case time.Duration:
+		return time.ParseDuration(s)
+	case string:
+		return s, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", p.Default)
+}
+
+type paramValue struct {
+	param  Param
+	values Values
+}
+
+func (v paramValue) String() string {
+	if text, found := v.values[v.param.Name]; found {
+		return text
+	}
+	if v.param.Default == nil {
+		return ""
+	}
+	return fmt.Sprint(v.param.Default)
+}
+
+func (v paramValue) IsBoolFlag() bool {
+	_, isBool := v.param.Default.(bool)
+	return isBool
+}
+
+// Set validates every value of the list and keeps the text as is.
+// It is parsed once again by Expand.
+func (v paramValue) Set(s string) error {
+	for _, item := range strings.Split(s, ",") {
+		if _, err := v.param.parse(item); err != nil {
+			return err
+		}
+	}
+	v.values[v.param.Name] = s
+	return nil
+}
+
+// Int returns the value of the int parameter.
+func (b *B) Int(name string) int {
+	return b.param(name).(int)
+}
+
+// Float returns the value of the float64 parameter.
+func (b *B) Float(name string) float64 {
+	return b.param(name).(float64)
+}
+
+// String returns the value of the string parameter.
+func (b *B) String(name string) string {
+	return b.param(name).(string)
+}
+
+// Bool returns the value of the bool parameter.
+func (b *B) Bool(name string) bool {
+	return b.param(name).(bool)
+}
+
+// Duration returns the value of the time.Duration parameter.
+func (b *B) Duration(name string) time.Duration {
+	return b.param(name).(time.Duration)
+}
+
+func (b *B) param(name string) any {
+	value, found := b.result.Params[name]
+	if found == false {
+		panic(fmt.Sprintf("harness: scenario %q has no parameter %q", b.result.Scenario, name))
+	}
+	return value
+}
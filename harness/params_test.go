@@ -0,0 +1,74 @@
+package harness
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	s := Scenario{
+		Name: "test",
+		Params: []Param{
+			{Name: "pings", Default: 1},
+			{Name: "payload", Default: "int"},
+			{Name: "duration", Default: time.Duration(0)},
+		},
+	}
+
+	values := make(Values)
+	fs := s.FlagSet(values)
+	if err := fs.Parse([]string{"-pings", "1,2,4", "-payload", "int,bytes1k"}); err != nil {
+		t.Fatal(err)
+	}
+	sweep, err := s.Expand(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"duration=0s payload=int pings=1",
+		"duration=0s payload=bytes1k pings=1",
+		"duration=0s payload=int pings=2",
+		"duration=0s payload=bytes1k pings=2",
+		"duration=0s payload=int pings=4",
+		"duration=0s payload=bytes1k pings=4",
+	}
+	if len(sweep) != len(expected) {
+		t.Fatalf("expected %d runs, got %d", len(expected), len(sweep))
+	}
+	for i, params := range sweep {
+		if params.String() != expected[i] {
+			t.Fatalf("run %d: expected %q, got %q", i, expected[i], params.String())
+		}
+	}
+
+	if err := fs.Parse([]string{"-pings", "1,x"}); err == nil {
+		t.Fatal("expected an error for the invalid value")
+	}
+}
+
+func TestChangedParams(t *testing.T) {
+	Register(Scenario{
+		Name: "test/changed",
+		Params: []Param{
+			{Name: "messages", Default: 1_000_000},
+			{Name: "duration", Default: 10 * time.Second},
+			{Name: "mode", Default: "send"},
+		},
+		Run: func(b *B) error { return nil },
+	})
+
+	// as read from JSON: the numbers are float64, the durations are text
+	r := Result{
+		Scenario: "test/changed",
+		Params:   Params{"messages": float64(1_000_000), "duration": "10s", "mode": "rtt"},
+	}
+	if changed := r.ChangedParams().String(); changed != "mode=rtt" {
+		t.Fatalf("expected mode=rtt, got %q", changed)
+	}
+
+	r.Params["messages"] = float64(2_000_000)
+	if changed := r.ChangedParams().String(); changed != "messages=2000000 mode=rtt" {
+		t.Fatalf("expected messages=2000000 mode=rtt, got %q", changed)
+	}
+}
@@ -0,0 +1,228 @@
+package harness
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Profile kinds
+const (
+	ProfileCPU       = "cpu"
+	ProfileHeap      = "heap"
+	ProfileAllocs    = "allocs"
+	ProfileMutex     = "mutex"
+	ProfileBlock     = "block"
+	ProfileGoroutine = "goroutine"
+	ProfileTrace     = "trace"
+)
+
+// ProfileKinds lists all the profile kinds.
+var ProfileKinds = []string{
+	ProfileCPU, ProfileHeap, ProfileAllocs, ProfileMutex, ProfileBlock, ProfileGoroutine, ProfileTrace,
+}
+
+// Profiles selects the profiles captured within the measured window of the
+// scenarios (between StartTimer and StopTimer). The warm-up iterations are not
+// profiled. The profiles of a scenario are written to its own subdirectory
+// of Dir: cpu.pprof, heap.pprof, ..., trace.out. The iteration number is added
+// to the file names starting from the second one (cpu-2.pprof).
+//
+// The allocs, mutex and block profiles are cumulative, so the snapshot taken
+// at the start of the window is written as well (allocs-base.pprof) to get
+// the window only: go tool pprof -base allocs-base.pprof allocs.pprof
+type Profiles struct {
+	Dir   string
+	Kinds []string
+}
+
+var profiles Profiles
+
+// SetProfiles enables the profiles for the scenarios run afterwards.
+func SetProfiles(p Profiles) {
+	profiles = p
+}
+
+// ParseProfiles parses the comma separated list of the profile kinds ("all"
+// for every kind) to be written to the directory.
+func ParseProfiles(list string, dir string) (Profiles, error) {
+	p := Profiles{Dir: dir}
+	for _, kind := range strings.Split(list, ",") {
+		kind = strings.TrimSpace(kind)
+		switch {
+		case kind == "":
+			continue
+		case kind == "all":
+			p.Kinds = ProfileKinds
+			return p, nil
+		case contains(ProfileKinds, kind) == false:
+			return Profiles{}, fmt.Errorf("unknown profile %q (expected %s or all)", kind, strings.Join(ProfileKinds, ", "))
+		case contains(p.Kinds, kind):
+			continue
+		}
+		p.Kinds = append(p.Kinds, kind)
+	}
+	return p, nil
+}
+
+func (p Profiles) enabled(kind string) bool {
+	return contains(p.Kinds, kind)
+}
+
+// profiler captures the profiles of a single run.
+type profiler struct {
+	Profiles
+	dir    string
+	suffix string
+
+	cpu     *os.File
+	trace   *os.File
+	started bool
+	err     error
+}
+
+func newProfiler(p Profiles, r Result) *profiler {
+	pr := &profiler{
+		Profiles: p,
+		dir:      filepath.Join(p.Dir, profileDir(r)),
+	}
+	if r.Iteration > 1 {
+		pr.suffix = "-" + strconv.Itoa(r.Iteration)
+	}
+	return pr
+}
+
+func (p *profiler) start() {
+	if p.started || p.err != nil {
+		return
+	}
+	p.started = true
+
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		p.err = err
+		return
+	}
+
+	if p.enabled(ProfileMutex) {
+		runtime.SetMutexProfileFraction(1)
+		p.write(ProfileMutex, "-base")
+	}
+	if p.enabled(ProfileBlock) {
+		runtime.SetBlockProfileRate(1)
+		p.write(ProfileBlock, "-base")
+	}
+	if p.enabled(ProfileAllocs) {
+		// the memory profile is updated by GC
+		runtime.GC()
+		p.write(ProfileAllocs, "-base")
+	}
+
+	if p.enabled(ProfileTrace) {
+		p.trace = p.create("trace" + p.suffix + ".out")
+		if p.trace != nil {
+			if err := trace.Start(p.trace); err != nil {
+				p.fail(err)
+			}
+		}
+	}
+	if p.enabled(ProfileCPU) {
+		p.cpu = p.create("cpu" + p.suffix + ".pprof")
+		if p.cpu != nil {
+			if err := pprof.StartCPUProfile(p.cpu); err != nil {
+				p.fail(err)
+			}
+		}
+	}
+}
+
+func (p *profiler) stop() {
+	if p.started == false {
+		return
+	}
+	p.started = false
+
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		p.fail(p.cpu.Close())
+		p.cpu = nil
+	}
+	if p.trace != nil {
+		trace.Stop()
+		p.fail(p.trace.Close())
+		p.trace = nil
+	}
+
+	if p.enabled(ProfileMutex) {
+		p.write(ProfileMutex, "")
+		runtime.SetMutexProfileFraction(0)
+	}
+	if p.enabled(ProfileBlock) {
+		p.write(ProfileBlock, "")
+		runtime.SetBlockProfileRate(0)
+	}
+	if p.enabled(ProfileGoroutine) {
+		p.write(ProfileGoroutine, "")
+	}
+	if p.enabled(ProfileAllocs) || p.enabled(ProfileHeap) {
+		runtime.GC()
+	}
+	if p.enabled(ProfileAllocs) {
+		p.write(ProfileAllocs, "")
+	}
+	if p.enabled(ProfileHeap) {
+		p.write(ProfileHeap, "")
+	}
+}
+
+// write writes the profile to <kind><base><suffix>.pprof
+func (p *profiler) write(kind string, base string) {
+	f := p.create(kind + base + p.suffix + ".pprof")
+	if f == nil {
+		return
+	}
+	p.fail(pprof.Lookup(kind).WriteTo(f, 0))
+	p.fail(f.Close())
+}
+
+func (p *profiler) create(name string) *os.File {
+	f, err := os.Create(filepath.Join(p.dir, name))
+	if err != nil {
+		p.fail(err)
+		return nil
+	}
+	return f
+}
+
+// fail keeps the first error
+func (p *profiler) fail(err error) {
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+}
+
+// profileDir returns the directory name of the run: the scenario name followed
+// by the parameters that differ from the defaults, e.g.
+// "ping-local-11_messages=1000".
+func profileDir(r Result) string {
+	clean := func(s string) string {
+		return strings.Map(func(c rune) rune {
+			if unicode.IsSpace(c) || c == '/' || c == '\\' || c == ':' {
+				return '-'
+			}
+			return c
+		}, s)
+	}
+
+	name := clean(r.Scenario)
+	changed := r.ChangedParams()
+	for _, param := range changed.Names() {
+		name += "_" + param + "=" + clean(FormatParam(changed[param]))
+	}
+	return name
+}
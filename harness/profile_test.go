@@ -0,0 +1,58 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProfiles(t *testing.T) {
+	if _, err := ParseProfiles("cpu,bogus", ""); err == nil {
+		t.Fatal("expected an error for the unknown profile")
+	}
+
+	p, err := ParseProfiles("all", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProfiles(p)
+	defer SetProfiles(Profiles{})
+
+	s := Scenario{
+		Name:   "test/profile",
+		Params: []Param{{Name: "n", Default: 1}},
+		Run: func(b *B) error {
+			b.StartTimer()
+			time.Sleep(10 * time.Millisecond)
+			b.StopTimer()
+			return nil
+		},
+	}
+	for iteration := 0; iteration <= 2; iteration++ {
+		if _, err := run(s, Params{"n": 2}, CurrentEnvironment(), iteration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "test-profile_n=2" {
+		t.Fatalf("unexpected directories: %v", entries)
+	}
+	dir := filepath.Join(p.Dir, entries[0].Name())
+	for _, name := range []string{
+		"cpu.pprof", "heap.pprof", "allocs.pprof", "allocs-base.pprof", "mutex.pprof", "mutex-base.pprof",
+		"block.pprof", "block-base.pprof", "goroutine.pprof", "trace.out", "cpu-2.pprof", "trace-2.out",
+	} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+			t.Fatalf("%s is missing or empty: %v", name, err)
+		}
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 20 {
+		t.Fatalf("expected 20 files (2 iterations, the warm-up is not profiled), got %d", len(files))
+	}
+}
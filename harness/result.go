@@ -0,0 +1,72 @@
+package harness
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// UnitNanoseconds is the unit of the metrics reported with ReportDuration
+	UnitNanoseconds = "ns"
+)
+
+// Result is the outcome of a single scenario run.
+type Result struct {
+	Scenario  string        `json:"scenario"`
+	Params    Params        `json:"params"`
+	Iteration int           `json:"iteration"` // starting from 1
+	Env       Environment   `json:"env"`
+	Start     time.Time     `json:"start"`
+	Elapsed   time.Duration `json:"elapsed_ns"`
+	Ops       int           `json:"ops"`
+	Metrics   []Metric      `json:"metrics"`
+}
+
+// Metric is a single value measured by the scenario.
+type Metric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// Metric returns the metric of the result by its name.
+func (r Result) Metric(name string) (Metric, bool) {
+	for _, m := range r.Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+func (m Metric) String() string {
+	if m.Unit == UnitNanoseconds {
+		return time.Duration(m.Value).String()
+	}
+	return fmt.Sprintf("%.2f %s", m.Value, m.Unit)
+}
+
+func printResult(r Result) {
+	fmt.Printf("\n")
+	fmt.Printf("=================================================================\n")
+	if r.Iteration > 0 {
+		fmt.Printf("RESULTS: %s (iteration %d)\n", r.Scenario, r.Iteration)
+	} else {
+		fmt.Printf("RESULTS: %s\n", r.Scenario)
+	}
+	fmt.Printf("=================================================================\n")
+	// the lines are indented, so benchstat doesn't take them for
+	// configuration lines if the output is mixed with the benchmark format
+	for _, name := range r.Params.Names() {
+		fmt.Printf("  %-22s %v\n", name+":", r.Params[name])
+	}
+	fmt.Printf("  %-22s %s\n", "elapsed:", r.Elapsed)
+	if r.Ops > 0 {
+		fmt.Printf("  %-22s %d\n", "operations:", r.Ops)
+	}
+	for _, m := range r.Metrics {
+		fmt.Printf("  %-22s %s\n", m.Name+":", m)
+	}
+	fmt.Printf("=================================================================\n")
+	fmt.Printf("\n")
+}
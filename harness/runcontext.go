@@ -0,0 +1,112 @@
+package harness
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RunContext coordinates the processes of a single scenario run. The scenario
+// creates it for every run and passes it to the processes in the Init
+// arguments, so nothing is shared between the runs and the scenarios can
+// run repeatedly or side by side.
+//
+// It keeps a readiness barrier (the processes are ready to start), a completion
+// counter (the work is done), named counters, the merged latency histogram and
+// named timestamps.
+type RunContext struct {
+	ready sync.WaitGroup
+	done  sync.WaitGroup
+
+	mutex    sync.Mutex
+	counters map[string]*atomic.Int64
+	latency  *Histogram
+	marks    map[string]time.Time
+}
+
+// NewRunContext creates the context of a scenario run.
+func NewRunContext() *RunContext {
+	return &RunContext{
+		counters: make(map[string]*atomic.Int64),
+		latency:  NewHistogram(),
+		marks:    make(map[string]time.Time),
+	}
+}
+
+// Expect adds n processes the readiness barrier waits for.
+func (r *RunContext) Expect(n int) {
+	r.ready.Add(n)
+}
+
+// Ready tells the barrier the process is ready.
+func (r *RunContext) Ready() {
+	r.ready.Done()
+}
+
+// WaitReady blocks until all the expected processes are ready.
+func (r *RunContext) WaitReady() {
+	r.ready.Wait()
+}
+
+// Add adds n to the completion counter.
+func (r *RunContext) Add(n int) {
+	r.done.Add(n)
+}
+
+// Done decrements the completion counter.
+func (r *RunContext) Done() {
+	r.done.Done()
+}
+
+// Wait blocks until the completion counter drops to zero.
+func (r *RunContext) Wait() {
+	r.done.Wait()
+}
+
+// Counter returns the named counter, creating it if needed.
+func (r *RunContext) Counter(name string) *atomic.Int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, found := r.counters[name]
+	if found == false {
+		c = new(atomic.Int64)
+		r.counters[name] = c
+	}
+	return c
+}
+
+// RecordLatency merges the histogram of a process into the latency of the run.
+func (r *RunContext) RecordLatency(h *Histogram) {
+	r.mutex.Lock()
+	r.latency.Merge(h)
+	r.mutex.Unlock()
+}
+
+// Latency returns the latency histogram merged from all the processes.
+func (r *RunContext) Latency() *Histogram {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.latency
+}
+
+// Mark records the current time under the name. If several processes mark
+// the same name, the latest time is kept, e.g. the time the last subscriber
+// received the event.
+func (r *RunContext) Mark(name string) {
+	now := time.Now()
+
+	r.mutex.Lock()
+	if now.After(r.marks[name]) {
+		r.marks[name] = now
+	}
+	r.mutex.Unlock()
+}
+
+// Time returns the time recorded under the name, or zero time if it's not
+// marked.
+func (r *RunContext) Time(name string) time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.marks[name]
+}
@@ -0,0 +1,40 @@
+package harness
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunContext(t *testing.T) {
+	rc := NewRunContext()
+
+	const n = 10
+	rc.Expect(n)
+	rc.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			rc.Ready()
+			rc.Counter("sent").Add(int64(i))
+			h := NewHistogram()
+			h.Record(time.Duration(i + 1))
+			rc.RecordLatency(h)
+			rc.Mark("done")
+			rc.Done()
+		}(i)
+	}
+	rc.WaitReady()
+	rc.Wait()
+
+	if sent := rc.Counter("sent").Load(); sent != 45 {
+		t.Fatalf("expected 45, got %d", sent)
+	}
+	if count := rc.Latency().Count(); count != n {
+		t.Fatalf("expected %d latency values, got %d", n, count)
+	}
+	if rc.Time("done").IsZero() {
+		t.Fatal("expected the time to be marked")
+	}
+	if rc.Time("unknown").IsZero() == false {
+		t.Fatal("expected zero time for the unknown mark")
+	}
+}
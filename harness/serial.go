@@ -0,0 +1,286 @@
+package harness
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Codecs compared by the serialization benchmarks (the serial directory).
+const (
+	CodecEDF      = "EDF"
+	CodecEDFCache = "EDF (+cache)"
+	CodecProtobuf = "Protobuf"
+	CodecGob      = "Gob"
+)
+
+// Codecs lists the codecs in the order they are shown.
+var Codecs = []string{CodecEDF, CodecEDFCache, CodecProtobuf, CodecGob}
+
+// SerialResult is the result of a serialization benchmark, averaged over
+// its runs.
+type SerialResult struct {
+	// Type is the data type, e.g. "Complex Struct"
+	Type string
+	// Op is "Encode" or "Decode"
+	Op       string
+	Codec    string
+	NsOp     float64
+	BytesOp  float64
+	AllocsOp float64
+}
+
+// Case returns the name of the benchmark case, e.g. "Complex Struct Encode".
+func (r SerialResult) Case() string {
+	return r.Type + " " + r.Op
+}
+
+// SerialResults turns the benchmark lines of the serial benchmarks into
+// the results. The benchmarks are named Benchmark<Op><Type><Codec>, where
+// the codec is "Cached" (EDF with the type cache), "Protobuf", "Gob", "EDF"
+// or none (EDF). The repeated benchmarks (go test -count) are averaged.
+// The results are ordered by the first appearance of the type, then by Op
+// and the codec.
+func SerialResults(lines []BenchLine) []SerialResult {
+	type key struct {
+		typ, op, codec string
+	}
+	sums := make(map[key]*SerialResult)
+	counts := make(map[key]int)
+	var types []string
+
+	for _, line := range lines {
+		name := strings.TrimPrefix(line.Name, "Benchmark")
+		var k key
+		for _, op := range []string{"Encode", "Decode"} {
+			if strings.HasPrefix(name, op) {
+				k.op = op
+				name = strings.TrimPrefix(name, op)
+				break
+			}
+		}
+		if k.op == "" {
+			continue
+		}
+
+		k.codec = CodecEDF
+		switch {
+		case strings.HasSuffix(name, "Cached"):
+			k.codec = CodecEDFCache
+			name = strings.TrimSuffix(name, "Cached")
+		case strings.HasSuffix(name, "Protobuf"):
+			k.codec = CodecProtobuf
+			name = strings.TrimSuffix(name, "Protobuf")
+		case strings.HasSuffix(name, "Gob"):
+			k.codec = CodecGob
+			name = strings.TrimSuffix(name, "Gob")
+		case strings.HasSuffix(name, "EDF"):
+			name = strings.TrimSuffix(name, "EDF")
+		}
+		k.typ = splitCamelCase(name)
+
+		sum, found := sums[k]
+		if found == false {
+			sum = &SerialResult{Type: k.typ, Op: k.op, Codec: k.codec}
+			sums[k] = sum
+			if contains(types, k.typ) == false {
+				types = append(types, k.typ)
+			}
+		}
+		sum.NsOp += line.Values["ns/op"]
+		sum.BytesOp += line.Values["B/op"]
+		sum.AllocsOp += line.Values["allocs/op"]
+		counts[k]++
+	}
+
+	var results []SerialResult
+	for _, typ := range types {
+		for _, op := range []string{"Encode", "Decode"} {
+			for _, codec := range Codecs {
+				k := key{typ, op, codec}
+				sum, found := sums[k]
+				if found == false {
+					continue
+				}
+				n := float64(counts[k])
+				results = append(results, SerialResult{
+					Type:     sum.Type,
+					Op:       sum.Op,
+					Codec:    sum.Codec,
+					NsOp:     sum.NsOp / n,
+					BytesOp:  sum.BytesOp / n,
+					AllocsOp: sum.AllocsOp / n,
+				})
+			}
+		}
+	}
+	return results
+}
+
+// splitCamelCase splits the words: "ComplexStruct" -> "Complex Struct",
+// "ProcessID" -> "Process ID", "PID" -> "PID".
+func splitCamelCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, c := range runes {
+		if i > 0 && unicode.IsUpper(c) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// winnerNames are the codec names in the Winner column of the table
+var winnerNames = map[string]string{
+	CodecEDF:      "EDF",
+	CodecEDFCache: "EDF+Cache",
+	CodecProtobuf: "Protobuf",
+	CodecGob:      "Gob",
+}
+
+// WriteSerialTable writes the results as the markdown table of README.md:
+// a row per benchmark case with the time, memory and allocations per
+// operation of every codec, the winner (the fastest one) and how EDF compares
+// with the others. The cpu is shown below the table if it's not empty.
+func WriteSerialTable(w io.Writer, results []SerialResult, cpu string) error {
+	var b strings.Builder
+
+	b.WriteString("| Data Type |")
+	for _, codec := range Codecs {
+		fmt.Fprintf(&b, " %s |", codec)
+	}
+	b.WriteString(" Winner | EDF Advantage |\n")
+	b.WriteString("|-----------|")
+	for _, codec := range Codecs {
+		b.WriteString(strings.Repeat("-", len(codec)+2) + "|")
+	}
+	b.WriteString("---------|---------------|\n")
+
+	var cases []string
+	byCase := make(map[string]map[string]SerialResult)
+	for _, r := range results {
+		if _, exist := byCase[r.Case()]; exist == false {
+			cases = append(cases, r.Case())
+			byCase[r.Case()] = make(map[string]SerialResult)
+		}
+		byCase[r.Case()][r.Codec] = r
+	}
+
+	for _, c := range cases {
+		codecs := byCase[c]
+		winner := ""
+		for _, codec := range Codecs {
+			r, found := codecs[codec]
+			if found == false {
+				continue
+			}
+			if winner == "" || r.NsOp < codecs[winner].NsOp {
+				winner = codec
+			}
+		}
+
+		fmt.Fprintf(&b, "| %s |", c)
+		for _, codec := range Codecs {
+			r, found := codecs[codec]
+			switch {
+			case found == false:
+				b.WriteString(" - |")
+			case codec == winner:
+				fmt.Fprintf(&b, " **%s** |", formatSerialCell(r))
+			default:
+				fmt.Fprintf(&b, " %s |", formatSerialCell(r))
+			}
+		}
+		fmt.Fprintf(&b, " **%s** | %s |\n", winnerNames[winner], edfAdvantage(codecs, winner))
+	}
+
+	b.WriteString("\n*Format: `time ns/op, memory B/op, allocations/op`*\n")
+	b.WriteString("\n*Run with `go test -bench=. -benchmem`*\n")
+	if cpu != "" {
+		fmt.Fprintf(&b, "\n*Hardware: `%s`*\n", cpu)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatSerialCell(r SerialResult) string {
+	return fmt.Sprintf("%sns, %.0fB, %.0fa", formatNs(r.NsOp), r.BytesOp, r.AllocsOp)
+}
+
+// formatNs keeps 4 significant digits like the benchmark output does
+func formatNs(v float64) string {
+	switch {
+	case v >= 1000:
+		return fmt.Sprintf("%.0f", v)
+	case v >= 100:
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// edfAdvantage describes how the fastest of EDF and EDF (+cache) compares
+// with the other codecs, e.g. "47% faster than Protobuf, 69% faster than Gob"
+// or "EDF 14% slower, but 6x faster than Gob" if another codec won.
+func edfAdvantage(codecs map[string]SerialResult, winner string) string {
+	edf, found := codecs[CodecEDF]
+	if cached, exist := codecs[CodecEDFCache]; exist && (found == false || cached.NsOp < edf.NsOp) {
+		edf, found = cached, true
+	}
+	if found == false {
+		return "-"
+	}
+
+	var others []string
+	for _, codec := range []string{CodecProtobuf, CodecGob} {
+		r, exist := codecs[codec]
+		if exist == false || codec == winner {
+			continue
+		}
+		others = append(others, compareNs(edf.NsOp, r.NsOp, codec))
+	}
+
+	if winner == CodecEDF || winner == CodecEDFCache {
+		if len(others) == 0 {
+			return "-"
+		}
+		return strings.Join(others, ", ")
+	}
+
+	slower := fmt.Sprintf("%s %.0f%% slower", winnerNames[edf.Codec], 100*(edf.NsOp-codecs[winner].NsOp)/codecs[winner].NsOp)
+	if len(others) == 0 {
+		return slower
+	}
+	return slower + ", but " + strings.Join(others, ", ")
+}
+
+// compareNs compares the EDF time with the other codec's one. The difference
+// is the share of the other codec's time saved, a multiple if it's 5x or more.
+func compareNs(edf float64, other float64, codec string) string {
+	diff := (other - edf) / other
+	switch {
+	case math.Abs(diff) < 0.05:
+		return "competitive with " + codec
+	case edf > other:
+		return fmt.Sprintf("%.0f%% slower than %s", 100*(edf-other)/other, codec)
+	case other/edf >= 5:
+		return fmt.Sprintf("%.0fx faster than %s", other/edf, codec)
+	}
+	return fmt.Sprintf("%.0f%% faster than %s", 100*diff, codec)
+}
@@ -0,0 +1,94 @@
+package harness
+
+import (
+	"strings"
+	"testing"
+)
+
+const serialOutput = `goos: darwin
+goarch: arm64
+pkg: serial
+cpu: Apple M4 Max
+BenchmarkEncodeStringCached-16          	51163580	        23.45 ns/op	       0 B/op	       0 allocs/op
+BenchmarkEncodeString-16                	40047806	        29.96 ns/op	      53 B/op	       0 allocs/op
+BenchmarkEncodeStringProtobuf-16        	27040424	        44.37 ns/op	      32 B/op	       1 allocs/op
+BenchmarkEncodeStringGob-16             	15698383	        76.42 ns/op	      16 B/op	       1 allocs/op
+BenchmarkEncodeComplexStructEDF-16      	 4316050	       277.8 ns/op	     307 B/op	       6 allocs/op
+BenchmarkEncodeComplexStructEDF-16      	 4316050	       279.8 ns/op	     307 B/op	       6 allocs/op
+BenchmarkDecodeProcessIDGob-16          	  100000	      9000 ns/op	    1000 B/op	      20 allocs/op
+PASS
+ok  	serial	12.345s
+`
+
+func TestSerialResults(t *testing.T) {
+	lines, err := ParseBench(strings.NewReader(serialOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 7 || lines[0].Name != "BenchmarkEncodeStringCached" || lines[0].Values["ns/op"] != 23.45 {
+		t.Fatalf("unexpected lines: %v", lines)
+	}
+
+	results := SerialResults(lines)
+	expected := []string{
+		"String Encode EDF",
+		"String Encode EDF (+cache)",
+		"String Encode Protobuf",
+		"String Encode Gob",
+		"Complex Struct Encode EDF",
+		"Process ID Decode Gob",
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %v", len(expected), results)
+	}
+	for i, r := range results {
+		if r.Case()+" "+r.Codec != expected[i] {
+			t.Fatalf("result %d: expected %q, got %q", i, expected[i], r.Case()+" "+r.Codec)
+		}
+	}
+	if results[4].NsOp != 278.8 {
+		t.Fatalf("expected the repeated runs to be averaged, got %v", results[4].NsOp)
+	}
+}
+
+func TestWriteSerialTable(t *testing.T) {
+	lines, err := ParseBench(strings.NewReader(serialOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := WriteSerialTable(&b, SerialResults(lines), "Apple M4 Max"); err != nil {
+		t.Fatal(err)
+	}
+	table := b.String()
+
+	for _, row := range []string{
+		"| Data Type | EDF | EDF (+cache) | Protobuf | Gob | Winner | EDF Advantage |",
+		"| String Encode | 29.96ns, 53B, 0a | **23.45ns, 0B, 0a** | 44.37ns, 32B, 1a | 76.42ns, 16B, 1a | **EDF+Cache** | 47% faster than Protobuf, 69% faster than Gob |",
+		"| Complex Struct Encode | **278.8ns, 307B, 6a** | - | - | - | **EDF** | - |",
+		"| Process ID Decode | - | - | - | **9000ns, 1000B, 20a** | **Gob** | - |",
+		"*Hardware: `Apple M4 Max`*",
+	} {
+		if strings.Contains(table, row+"\n") == false {
+			t.Fatalf("missing %q in:\n%s", row, table)
+		}
+	}
+}
+
+func TestCompareNs(t *testing.T) {
+	cases := []struct {
+		edf, other float64
+		expected   string
+	}{
+		{224.7, 234.0, "competitive with Gob"},
+		{739.9, 1557, "52% faster than Gob"},
+		{468.8, 6733, "14x faster than Gob"},
+		{76.62, 67.45, "14% slower than Gob"},
+	}
+	for _, c := range cases {
+		if s := compareNs(c.edf, c.other, "Gob"); s != c.expected {
+			t.Fatalf("%v vs %v: expected %q, got %q", c.edf, c.other, c.expected, s)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package harness
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Summary describes the spread of a metric over the iterations of a scenario.
+type Summary struct {
+	Name   string  `json:"name"`
+	Unit   string  `json:"unit"`
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Median float64 `json:"median"`
+	// CI is the half-width of the 95% confidence interval of the mean
+	CI float64 `json:"ci95"`
+}
+
+// Summarize returns the summary of the elapsed time and of every metric over
+// the results of the iterations of a scenario.
+func Summarize(results []Result) []Summary {
+	if len(results) == 0 {
+		return nil
+	}
+
+	var names []string
+	values := make(map[string][]float64)
+	units := make(map[string]string)
+	add := func(name string, unit string, value float64) {
+		if _, exist := values[name]; exist == false {
+			names = append(names, name)
+			units[name] = unit
+		}
+		values[name] = append(values[name], value)
+	}
+
+	for _, r := range results {
+		add("elapsed", UnitNanoseconds, float64(r.Elapsed))
+		for _, m := range r.Metrics {
+			add(m.Name, m.Unit, m.Value)
+		}
+	}
+
+	summaries := make([]Summary, len(names))
+	for i, name := range names {
+		summaries[i] = summarize(name, units[name], values[name])
+	}
+	return summaries
+}
+
+func summarize(name string, unit string, values []float64) Summary {
+	s := Summary{Name: name, Unit: unit, N: len(values)}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	if len(sorted)%2 == 1 {
+		s.Median = sorted[len(sorted)/2]
+	} else {
+		s.Median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+
+	for _, v := range values {
+		s.Mean += v
+	}
+	s.Mean /= float64(len(values))
+
+	if len(values) < 2 {
+		return s
+	}
+	for _, v := range values {
+		s.StdDev += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(s.StdDev / float64(len(values)-1))
+	s.CI = studentT95(len(values)-1) * s.StdDev / math.Sqrt(float64(len(values)))
+	return s
+}
+
+// t95 holds the two-sided 95% quantiles of the Student's t-distribution
+// for 1..30 degrees of freedom
+var t95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func studentT95(df int) float64 {
+	if df < 1 {
+		return math.NaN()
+	}
+	if df <= len(t95) {
+		return t95[df-1]
+	}
+	// close enough to the normal distribution
+	return 1.960
+}
+
+func (s Summary) format(v float64) string {
+	return Metric{Value: v, Unit: s.Unit}.String()
+}
+
+func (s Summary) String() string {
+	if s.N < 2 {
+		return s.format(s.Mean)
+	}
+	ci := ""
+	if s.Mean != 0 {
+		ci = fmt.Sprintf(" (%.1f%%)", 100*s.CI/math.Abs(s.Mean))
+	}
+	return fmt.Sprintf("%s ± %s%s, stddev %s, median %s, min %s, max %s",
+		s.format(s.Mean), s.format(s.CI), ci, s.format(s.StdDev),
+		s.format(s.Median), s.format(s.Min), s.format(s.Max))
+}
+
+func printSummary(scenario string, summaries []Summary) {
+	if len(summaries) == 0 {
+		return
+	}
+	fmt.Printf("=================================================================\n")
+	fmt.Printf("SUMMARY: %s (%d iterations, mean ± 95%% CI)\n", scenario, summaries[0].N)
+	fmt.Printf("=================================================================\n")
+	for _, s := range summaries {
+		fmt.Printf("  %-22s %s\n", s.Name+":", s)
+	}
+	fmt.Printf("=================================================================\n")
+	fmt.Printf("\n")
+}
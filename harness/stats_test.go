@@ -0,0 +1,34 @@
+package harness
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	var results []Result
+	for i, v := range []float64{10, 12, 11, 13, 14} {
+		results = append(results, Result{
+			Elapsed: time.Duration(i+1) * time.Second,
+			Metrics: []Metric{{Name: "throughput", Value: v, Unit: "msg/sec"}},
+		})
+	}
+
+	summaries := Summarize(results)
+	if len(summaries) != 2 || summaries[0].Name != "elapsed" || summaries[1].Name != "throughput" {
+		t.Fatalf("unexpected summaries: %v", summaries)
+	}
+
+	s := summaries[1]
+	if s.N != 5 || s.Mean != 12 || s.Median != 12 || s.Min != 10 || s.Max != 14 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	if math.Abs(s.StdDev-math.Sqrt(2.5)) > 1e-9 {
+		t.Fatalf("unexpected stddev: %v", s.StdDev)
+	}
+	// t(0.975, 4) = 2.776
+	if math.Abs(s.CI-2.776*math.Sqrt(2.5)/math.Sqrt(5)) > 1e-9 {
+		t.Fatalf("unexpected confidence interval: %v", s.CI)
+	}
+}
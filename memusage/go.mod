@@ -1,16 +1,19 @@
 module memusage
 
-go 1.20
+go 1.21.6
 
 require (
 	ergo.services/ergo v1.999.321-0.20260327124509-b105ef09c1ba
-	ergo.services/logger/colored v0.0.0-20250904215210-2ed1711d3aba
-	github.com/klauspost/cpuid/v2 v2.2.6
+	harness v0.0.0-00010101000000-000000000000
 )
 
 require (
+	ergo.services/logger/colored v0.1.0 // indirect
 	github.com/fatih/color v1.18.0 // indirect
+	github.com/klauspost/cpuid/v2 v2.2.6 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 )
+
+replace harness => ../harness
@@ -0,0 +1,336 @@
+package memusage
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"time"
+
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+const (
+	// how the spawner keeps track of the spawned processes
+	watchNone    = "none"
+	watchLink    = "link"
+	watchMonitor = "monitor"
+
+	// how the spawner terminates them
+	terminateKill   = "kill"   // node.Kill
+	terminateExit   = "exit"   // exit signal
+	terminateNormal = "normal" // the process returns gen.TerminateReasonNormal
+
+	// counters of the run context
+	counterCycles     = "cycles"
+	counterTerminated = "terminated"
+	counterErrors     = "errors"
+)
+
+func init() {
+	watch := harness.Param{Name: "watch", Usage: "the spawner links to the processes (link), monitors them (monitor) or none", Default: watchNone}
+	terminate := harness.Param{Name: "terminate", Usage: "how the processes are terminated: kill, exit (exit signal) or normal (they return normal)", Default: terminateExit}
+
+	harness.Register(harness.Scenario{
+		Name:        "lifecycle/spawn",
+		Description: "spawn rate of the processes (optionally linked or monitored)",
+		Params: []harness.Param{
+			{Name: "processes", Usage: "number of processes to spawn", Default: 1_000_000},
+			watch,
+		},
+		Run: runSpawn,
+	})
+	harness.Register(harness.Scenario{
+		Name:        "lifecycle/terminate",
+		Description: "termination rate of the processes (optionally linked or monitored)",
+		Params: []harness.Param{
+			{Name: "processes", Usage: "number of processes to terminate", Default: 1_000_000},
+			watch,
+			terminate,
+		},
+		Run: runTerminate,
+	})
+	harness.Register(harness.Scenario{
+		Name:        "lifecycle/churn",
+		Description: "processes are spawned and terminated continuously, the memory is tracked over time",
+		Params: []harness.Param{
+			{Name: "spawners", Usage: "number of processes spawning and terminating the processes", Default: NCPU},
+			{Name: "live", Usage: "number of live processes kept by each spawner", Default: 1000},
+			{Name: "duration", Usage: "time to run", Default: 30 * time.Second},
+			{Name: "sample", Usage: "interval of the memory samples", Default: time.Second},
+			watch,
+			terminate,
+		},
+		Run: runChurn,
+	})
+}
+
+// NCPU is the default number of the spawners of the churn scenario
+var NCPU = runtime.NumCPU()
+
+// spawnAll makes the spawner spawn the processes
+type spawnAll struct {
+	n int
+}
+
+// terminateAll makes the spawner terminate all the processes it spawned
+type terminateAll struct{}
+
+// churn makes the spawner spawn and terminate processes until the deadline
+type churn struct {
+	live     int
+	deadline time.Time
+}
+
+// stop makes the victim terminate with the normal reason
+type stop struct{}
+
+func runSpawn(b *harness.B) error {
+	np := b.Int("processes")
+	node, spawner, rc, err := startSpawner(b, np, terminateExit)
+	if err != nil {
+		return err
+	}
+
+	node.Log().Info("BENCHMARK: spawning %d processes (watch: %s)", np, b.String("watch"))
+	rc.Add(1)
+	b.StartTimer()
+	if err := node.Send(spawner, spawnAll{n: np}); err != nil {
+		return err
+	}
+	rc.Wait()
+	elapsed := b.StopTimer()
+	if err := spawnerError(rc); err != nil {
+		return err
+	}
+
+	runtime.GC()
+	info, err := node.Info()
+	if err != nil {
+		return err
+	}
+	b.SetOps(np)
+	b.ReportMetric("spawn rate", float64(np)/elapsed.Seconds(), "proc/sec")
+	b.ReportDuration("spawn time", elapsed/time.Duration(np))
+	b.ReportMetric("memory per process", float64(info.MemoryAlloc)/float64(info.ProcessesTotal)/1024.0, "KB")
+	return nil
+}
+
+func runTerminate(b *harness.B) error {
+	np := b.Int("processes")
+	terminate := b.String("terminate")
+	node, spawner, rc, err := startSpawner(b, np, terminate)
+	if err != nil {
+		return err
+	}
+
+	node.Log().Info("Spawning %d processes (watch: %s)...", np, b.String("watch"))
+	rc.Add(1)
+	if err := node.Send(spawner, spawnAll{n: np}); err != nil {
+		return err
+	}
+	rc.Wait()
+	if err := spawnerError(rc); err != nil {
+		return err
+	}
+	before, err := node.Info()
+	if err != nil {
+		return err
+	}
+
+	node.Log().Info("BENCHMARK: terminating %d processes (%s)", np, terminate)
+	rc.Add(1)
+	b.StartTimer()
+	if err := node.Send(spawner, terminateAll{}); err != nil {
+		return err
+	}
+	rc.Wait()
+	if terminate != terminateKill {
+		// node.Kill is synchronous, the exit signals and the stop messages
+		// are handled by the processes themselves
+		if err := waitCounter(rc, counterTerminated, int64(np), time.Minute); err != nil {
+			return err
+		}
+	}
+	elapsed := b.StopTimer()
+	if err := spawnerError(rc); err != nil {
+		return err
+	}
+
+	after, err := harness.WaitProcesses(node, before.ProcessesTotal-int64(np), 5*time.Second)
+	if err != nil {
+		return err
+	}
+	if left := after.ProcessesTotal - (before.ProcessesTotal - int64(np)); left > 0 {
+		return fmt.Errorf("%d processes are still alive", left)
+	}
+
+	b.SetOps(np)
+	b.ReportMetric("terminate rate", float64(np)/elapsed.Seconds(), "proc/sec")
+	b.ReportDuration("terminate time", elapsed/time.Duration(np))
+	return nil
+}
+
+func runChurn(b *harness.B) error {
+	ns := b.Int("spawners")
+	live := b.Int("live")
+	duration := b.Duration("duration")
+	interval := b.Duration("sample")
+	if ns < 1 {
+		return fmt.Errorf("number of spawners must be positive")
+	}
+	if live < 0 {
+		return fmt.Errorf("number of live processes must not be negative")
+	}
+	if duration <= 0 || interval <= 0 {
+		return fmt.Errorf("duration and sample interval must be positive")
+	}
+
+	node, err := b.StartNode("churn@localhost", gen.NodeOptions{})
+	if err != nil {
+		return err
+	}
+	spawners := make([]gen.PID, ns)
+	rc := harness.NewRunContext()
+	for i := range spawners {
+		spawners[i], err = node.Spawn(factory_spawner, gen.ProcessOptions{}, rc, b.String("watch"), b.String("terminate"))
+		if err != nil {
+			return err
+		}
+	}
+
+	runtime.GC()
+	start, err := node.Info()
+	if err != nil {
+		return err
+	}
+
+	node.Log().Info("BENCHMARK: %d processes spawn and terminate processes for %s (%d live each, watch: %s, terminate: %s)",
+		ns, duration, live, b.String("watch"), b.String("terminate"))
+	rc.Add(ns)
+	b.StartTimer()
+	m := churn{live: live, deadline: time.Now().Add(duration)}
+	for _, pid := range spawners {
+		if err := node.Send(pid, m); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		rc.Wait()
+		close(done)
+	}()
+
+	// memory samples: seconds since the start and KB of the live heap
+	var xs, ys []float64
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-ticker.C:
+			// the live heap as of the last GC cycle, so the garbage doesn't
+			// look like a leak. Forcing GC here would slow down the churn.
+			since := time.Since(m.deadline.Add(-duration))
+			xs = append(xs, since.Seconds())
+			ys = append(ys, float64(heapLive())/1024.0)
+			node.Log().Info("%s: live heap %.2f Kb", since.Round(time.Second), ys[len(ys)-1])
+		}
+	}
+	elapsed := b.StopTimer()
+	if err := spawnerError(rc); err != nil {
+		return err
+	}
+
+	// the exit signals and the stop messages are handled asynchronously
+	end, err := harness.WaitProcesses(node, start.ProcessesTotal, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if end, err = node.Info(); err != nil {
+		return err
+	}
+
+	cycles := rc.Counter(counterCycles).Load()
+	b.SetOps(int(cycles))
+	b.ReportMetric("churn rate", float64(cycles)/elapsed.Seconds(), "proc/sec")
+	b.ReportMetric("memory start", float64(start.MemoryAlloc)/1024.0, "KB")
+	b.ReportMetric("memory end", float64(end.MemoryAlloc)/1024.0, "KB")
+	b.ReportMetric("memory growth", (float64(end.MemoryAlloc)-float64(start.MemoryAlloc))/1024.0, "KB")
+	b.ReportMetric("memory slope", slope(xs, ys), "+KB/sec")
+	b.ReportMetric("processes leaked", float64(end.ProcessesTotal-start.ProcessesTotal), "proc")
+	return nil
+}
+
+// startSpawner starts the node and the spawner process on it.
+func startSpawner(b *harness.B, np int, terminate string) (gen.Node, gen.PID, *harness.RunContext, error) {
+	if np < 1 {
+		return nil, gen.PID{}, nil, fmt.Errorf("number of processes must be positive")
+	}
+	node, err := b.StartNode("lifecycle@localhost", gen.NodeOptions{})
+	if err != nil {
+		return nil, gen.PID{}, nil, err
+	}
+	rc := harness.NewRunContext()
+	spawner, err := node.Spawn(factory_spawner, gen.ProcessOptions{}, rc, b.String("watch"), terminate)
+	if err != nil {
+		return nil, gen.PID{}, nil, err
+	}
+	return node, spawner, rc, nil
+}
+
+// spawnerError returns the error the spawner failed with.
+func spawnerError(rc *harness.RunContext) error {
+	if rc.Counter(counterErrors).Load() > 0 {
+		return fmt.Errorf("spawner failed, see the log")
+	}
+	return nil
+}
+
+// waitCounter waits until the counter reaches n. It fails if it doesn't
+// within the timeout.
+func waitCounter(rc *harness.RunContext, name string, n int64, timeout time.Duration) error {
+	counter := rc.Counter(name)
+	deadline := time.Now().Add(timeout)
+	for counter.Load() < n {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s: %d of %d in %s", name, counter.Load(), n, timeout)
+		}
+		time.Sleep(50 * time.Microsecond)
+	}
+	return nil
+}
+
+// heapLive returns the heap memory marked live by the last GC cycle.
+func heapLive() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/live:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// slope returns the slope of the least squares line, 0 if there are less than
+// 2 points.
+func slope(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / d
+}
@@ -0,0 +1,77 @@
+package memusage
+
+import (
+	"testing"
+	"time"
+
+	"harness"
+)
+
+func TestSpawn(t *testing.T) {
+	for _, watch := range []string{watchNone, watchLink, watchMonitor} {
+		t.Run(watch, func(t *testing.T) {
+			r, err := harness.RunOnce("lifecycle/spawn", harness.Params{"processes": 500, "watch": watch})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Ops != 500 {
+				t.Fatalf("expected 500 processes, got %d", r.Ops)
+			}
+			if m, found := r.Metric("spawn rate"); found == false || m.Value <= 0 {
+				t.Fatalf("expected positive spawn rate, got %f", m.Value)
+			}
+		})
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	for _, watch := range []string{watchNone, watchLink, watchMonitor} {
+		for _, terminate := range []string{terminateKill, terminateExit, terminateNormal} {
+			t.Run(watch+"/"+terminate, func(t *testing.T) {
+				// the run fails if any of the processes is still alive
+				r, err := harness.RunOnce("lifecycle/terminate", harness.Params{
+					"processes": 500,
+					"watch":     watch,
+					"terminate": terminate,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Ops != 500 {
+					t.Fatalf("expected 500 processes, got %d", r.Ops)
+				}
+			})
+		}
+	}
+}
+
+func TestChurn(t *testing.T) {
+	r, err := harness.RunOnce("lifecycle/churn", harness.Params{
+		"spawners": 2,
+		"live":     100,
+		"duration": 300 * time.Millisecond,
+		"sample":   50 * time.Millisecond,
+		"watch":    watchMonitor,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops == 0 {
+		t.Fatalf("expected the processes to churn")
+	}
+	if m, found := r.Metric("processes leaked"); found == false || m.Value != 0 {
+		t.Fatalf("expected no processes leaked, got %.0f", m.Value)
+	}
+}
+
+func TestSlope(t *testing.T) {
+	if s := slope([]float64{0, 1, 2, 3}, []float64{1, 3, 5, 7}); s != 2 {
+		t.Fatalf("expected slope 2, got %f", s)
+	}
+	if s := slope([]float64{1}, []float64{5}); s != 0 {
+		t.Fatalf("expected slope 0 of a single point, got %f", s)
+	}
+	if s := slope([]float64{1, 1}, []float64{1, 2}); s != 0 {
+		t.Fatalf("expected slope 0 of a vertical line, got %f", s)
+	}
+}
@@ -1,18 +1,12 @@
-package main
+package memusage
 
 import (
 	"runtime"
 	"time"
 
-	"ergo.services/ergo"
 	"ergo.services/ergo/act"
 	"ergo.services/ergo/gen"
-	"ergo.services/logger/colored"
-	. "github.com/klauspost/cpuid/v2"
-)
-
-var (
-	NCPU int = runtime.NumCPU()
+	"harness"
 )
 
 func factory_simple() gen.ProcessBehavior {
@@ -23,29 +17,26 @@ type simple struct {
 	act.Actor
 }
 
-func main() {
-	var options gen.NodeOptions
-
-	options.Network.Cookie = "123"
-	loggercolored, err := colored.CreateLogger(colored.Options{
-		TimeFormat:    time.DateTime,
-		DisableBanner: true,
+func init() {
+	harness.Register(harness.Scenario{
+		Name:        "memusage",
+		Description: "memory usage per process",
+		Params: []harness.Param{
+			{Name: "processes", Usage: "number of processes to start", Default: 1_000_000},
+		},
+		Run: runMemUsage,
 	})
+}
+
+func runMemUsage(b *harness.B) error {
+	node, err := b.StartNode("mem@localhost", gen.NodeOptions{})
 	if err != nil {
-		panic(err)
-	}
-	options.Log.DefaultLogger.Disable = true
-	options.Log.Loggers = append(
-		options.Log.Loggers,
-		gen.Logger{Name: "colored", Logger: loggercolored},
-	)
-	node, err := ergo.StartNode("mem@localhost", options)
-	if err != nil {
-		panic(err)
+		return err
 	}
 
+	var info gen.NodeInfo
 	mem := func(proc bool) {
-		info, _ := node.Info()
+		info, _ = node.Info()
 		node.Log().Info("Memory allocated (runtime): %.2f Kb", float64(info.MemoryAlloc)/1024.0)
 		node.Log().Info("Memory used (OS): %.2f Kb", float64(info.MemoryUsed)/1024.0)
 		if proc {
@@ -55,25 +46,27 @@ func main() {
 		}
 		runtime.GC()
 	}
-	node.Log().Info("-------------------------- Memory usage (start) ----------------------------------")
-	node.Log().Info("Go Version : %s", runtime.Version())
-	node.Log().Info("CPU: %s (Physical Cores: %d)", CPU.BrandName, CPU.PhysicalCores)
-	node.Log().Info("Runtime CPUs: %d", NCPU)
 
+	np := b.Int("processes")
 	mem(false)
-	node.Log().Info("Starting 1M processes...")
-	start := time.Now()
-	for i := 0; i < 1000000; i++ {
+	node.Log().Info("Starting %d processes...", np)
+	b.StartTimer()
+	for i := 0; i < np; i++ {
 		if _, err := node.Spawn(factory_simple, gen.ProcessOptions{}); err != nil {
-			panic(err)
+			return err
 		}
 	}
-	node.Log().Info("1M processes is started. Elapsed: %s", time.Since(start))
+	elapsed := b.StopTimer()
+	node.Log().Info("%d processes is started. Elapsed: %s", np, elapsed)
 
 	for i := 0; i < 3; i++ {
 		mem(true)
 		time.Sleep(time.Second)
 	}
-	node.Log().Info("-------------------------- Memory usage (end) ----------------------------------")
 
+	b.SetOps(np)
+	b.ReportMetric("memory allocated", float64(info.MemoryAlloc)/1024.0, "KB")
+	b.ReportMetric("memory used", float64(info.MemoryUsed)/1024.0, "KB")
+	b.ReportMetric("memory per process", (float64(info.MemoryAlloc)/float64(info.ProcessesTotal))/1024.0, "KB")
+	return nil
 }
@@ -0,0 +1,169 @@
+package memusage
+
+import (
+	"time"
+
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+// churnBatch is the number of the spawn/terminate cycles the spawner makes
+// before it handles the other messages (the exit signals and the down
+// messages of the linked and monitored processes)
+const churnBatch = 100
+
+func factory_spawner() gen.ProcessBehavior {
+	return &spawner{}
+}
+
+// spawner spawns the victim processes and terminates them.
+type spawner struct {
+	act.Actor
+
+	rc        *harness.RunContext
+	watch     string
+	terminate string
+
+	pids  []gen.PID
+	churn churn
+}
+
+type churnStep struct{}
+
+func (s *spawner) Init(args ...any) error {
+	s.rc = args[0].(*harness.RunContext)
+	s.watch = args[1].(string)
+	s.terminate = args[2].(string)
+	if s.watch == watchLink {
+		// the linked processes must not take the spawner down
+		s.SetTrapExit(true)
+	}
+	return nil
+}
+
+func (s *spawner) HandleMessage(from gen.PID, message any) error {
+	switch m := message.(type) {
+	case spawnAll:
+		for i := 0; i < m.n; i++ {
+			pid, err := s.spawn()
+			if err != nil {
+				return s.fail(err)
+			}
+			s.pids = append(s.pids, pid)
+		}
+		s.rc.Done()
+
+	case terminateAll:
+		for _, pid := range s.pids {
+			if err := s.terminateProcess(pid); err != nil {
+				return s.fail(err)
+			}
+		}
+		s.pids = nil
+		s.rc.Done()
+
+	case churn:
+		s.churn = m
+		return s.churnStep()
+
+	case churnStep:
+		return s.churnStep()
+	}
+
+	// exit signals and down messages of the watched processes
+	return nil
+}
+
+// churnStep spawns a batch of processes and terminates the oldest ones, so the
+// spawner keeps the given number of live processes.
+func (s *spawner) churnStep() error {
+	if time.Now().After(s.churn.deadline) {
+		for _, pid := range s.pids {
+			if err := s.terminateProcess(pid); err != nil {
+				return s.fail(err)
+			}
+		}
+		s.pids = nil
+		s.rc.Done()
+		return nil
+	}
+
+	cycles := 0
+	for i := 0; i < churnBatch; i++ {
+		pid, err := s.spawn()
+		if err != nil {
+			return s.fail(err)
+		}
+		s.pids = append(s.pids, pid)
+		if len(s.pids) <= s.churn.live {
+			continue
+		}
+		if err := s.terminateProcess(s.pids[0]); err != nil {
+			return s.fail(err)
+		}
+		s.pids = s.pids[1:]
+		cycles++
+	}
+	s.rc.Counter(counterCycles).Add(int64(cycles))
+	s.Send(s.PID(), churnStep{})
+	return nil
+}
+
+func (s *spawner) spawn() (gen.PID, error) {
+	options := gen.ProcessOptions{LinkParent: s.watch == watchLink}
+	pid, err := s.Spawn(factory_victim, options, s.rc)
+	if err != nil {
+		return pid, err
+	}
+	if s.watch == watchMonitor {
+		err = s.MonitorPID(pid)
+	}
+	return pid, err
+}
+
+func (s *spawner) terminateProcess(pid gen.PID) error {
+	switch s.terminate {
+	case terminateKill:
+		return s.Node().Kill(pid)
+	case terminateNormal:
+		return s.Send(pid, stop{})
+	}
+	return s.SendExit(pid, gen.TerminateReasonShutdown)
+}
+
+// fail reports the error and keeps the spawner alive, so the scenario isn't
+// left waiting.
+func (s *spawner) fail(err error) error {
+	s.Log().Error("spawner failed: %s", err)
+	s.rc.Counter(counterErrors).Add(1)
+	s.rc.Done()
+	return nil
+}
+
+func factory_victim() gen.ProcessBehavior {
+	return &victim{}
+}
+
+// victim does nothing but counts its termination.
+type victim struct {
+	act.Actor
+
+	rc *harness.RunContext
+}
+
+func (v *victim) Init(args ...any) error {
+	v.rc = args[0].(*harness.RunContext)
+	return nil
+}
+
+func (v *victim) HandleMessage(from gen.PID, message any) error {
+	if _, ok := message.(stop); ok {
+		return gen.TerminateReasonNormal
+	}
+	return nil
+}
+
+func (v *victim) Terminate(reason error) {
+	v.rc.Counter(counterTerminated).Add(1)
+}
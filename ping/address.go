@@ -0,0 +1,76 @@
+package ping
+
+import (
+	"fmt"
+
+	"ergo.services/ergo/gen"
+	"ergo.services/ergo/net/edf"
+)
+
+const (
+	// how the ping addresses the pong
+	addressPID   = "pid"   // gen.PID
+	addressName  = "name"  // gen.ProcessID, the registered name of the pong
+	addressAlias = "alias" // gen.Alias created by the pong
+)
+
+// addressRequest asks the pong for its name or alias. The pong registers the
+// name or creates the alias on the first request, so the pong spawned by any
+// node (even in another OS process) can be addressed either way.
+type addressRequest struct {
+	Kind string
+}
+
+func init() {
+	if err := edf.RegisterTypeOf(addressRequest{}); err != nil {
+		panic(err)
+	}
+}
+
+// resolve returns the address of the pong of the given kind.
+func (p *ping) resolve(kind string) (any, error) {
+	if kind == addressPID {
+		return p.pair, nil
+	}
+	return p.Call(p.pair, addressRequest{Kind: kind})
+}
+
+// sendPair sends the message to the pong using the address the ping was
+// started with.
+func (p *ping) sendPair(message any) error {
+	switch to := p.to.(type) {
+	case gen.PID:
+		return p.SendPID(to, message)
+	case gen.ProcessID:
+		return p.SendProcessID(to, message)
+	case gen.Alias:
+		return p.SendAlias(to, message)
+	}
+	return fmt.Errorf("unsupported address %#v", p.to)
+}
+
+// address registers the name or creates the alias once and returns it.
+func (p *pong) address(kind string) (any, error) {
+	switch kind {
+	case addressName:
+		if p.name == "" {
+			name := gen.Atom(fmt.Sprintf("pong%d", p.PID().ID))
+			if err := p.RegisterName(name); err != nil {
+				return nil, err
+			}
+			p.name = name
+		}
+		return gen.ProcessID{Name: p.name, Node: p.Node().Name()}, nil
+
+	case addressAlias:
+		if p.alias == (gen.Alias{}) {
+			alias, err := p.CreateAlias()
+			if err != nil {
+				return nil, err
+			}
+			p.alias = alias
+		}
+		return p.alias, nil
+	}
+	return p.PID(), nil
+}
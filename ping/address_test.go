@@ -0,0 +1,33 @@
+package ping
+
+import (
+	"testing"
+
+	"harness"
+)
+
+func TestPingAddress(t *testing.T) {
+	for _, name := range []string{"ping/local-NN", "ping/network-NN"} {
+		for _, address := range []string{addressName, addressAlias} {
+			for _, mode := range []string{modeSend, modeRTT} {
+				t.Run(name+"/"+address+"/"+mode, func(t *testing.T) {
+					// 2 pings per pong, so the pong is asked for its
+					// address twice and registers it once
+					r, err := harness.RunOnce(name, harness.Params{
+						"messages": 300,
+						"pings":    4,
+						"pongs":    2,
+						"mode":     mode,
+						"address":  address,
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if r.Ops != 1200 {
+						t.Fatalf("expected 1200 messages, got %d", r.Ops)
+					}
+				})
+			}
+		}
+	}
+}
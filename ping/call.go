@@ -0,0 +1,95 @@
+package ping
+
+import (
+	"fmt"
+	"time"
+
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+// callRequest is sent by the caller to the pong process. The pong answers
+// after the given delay (nanoseconds), emulating a slow responder.
+type callRequest struct {
+	Delay int64
+}
+
+type startCall struct {
+	n        int
+	duration time.Duration
+	timeout  int
+	delay    time.Duration
+	slow     int
+}
+
+// callParams returns the parameters of a call scenario with the given defaults.
+func callParams(calls int, processes int) []harness.Param {
+	return []harness.Param{
+		{Name: "calls", Usage: "number of calls made by each caller process", Default: calls},
+		{Name: "callers", Usage: "number of caller processes", Default: processes},
+		{Name: "pongs", Usage: "number of pong processes answering the calls, the callers are distributed among them evenly", Default: processes},
+		{Name: "duration", Usage: "make calls for the given time instead of the fixed number of calls", Default: time.Duration(0)},
+		{Name: "timeout", Usage: "call timeout in seconds", Default: 5},
+		{Name: "delay", Usage: "time the pong process takes to answer a slow call", Default: time.Duration(0)},
+		{Name: "slow", Usage: "every n-th call is slow (0 - none)", Default: 0},
+	}
+}
+
+// runCall starts the caller processes on the node, makes them call the pong
+// processes and waits until all the calls are done.
+func runCall(b *harness.B, nodeping gen.Node, pongs []gen.PID) error {
+	start := startCall{
+		n:        b.Int("calls"),
+		duration: b.Duration("duration"),
+		timeout:  b.Int("timeout"),
+		delay:    b.Duration("delay"),
+		slow:     b.Int("slow"),
+	}
+	np := b.Int("callers")
+	if np < 1 {
+		return fmt.Errorf("number of caller processes must be positive")
+	}
+	if start.n < 1 && start.duration == 0 {
+		return fmt.Errorf("number of calls must be positive")
+	}
+	if start.timeout < 1 {
+		return fmt.Errorf("timeout must be at least 1 second")
+	}
+
+	token, err := nodeping.RegisterEvent(EVENT.Name, gen.EventOptions{})
+	if err != nil {
+		return err
+	}
+
+	// starting caller processes
+	rc := harness.NewRunContext()
+	rc.Expect(np)
+	for i := 0; i < np; i++ {
+		if _, err := nodeping.Spawn(factory_caller, gen.ProcessOptions{}, pongs[i%len(pongs)], rc); err != nil {
+			return err
+		}
+	}
+	if start.duration > 0 {
+		nodeping.Log().Info("BENCHMARK: %d processes call %d processes for %s", np, len(pongs), start.duration)
+	} else {
+		nodeping.Log().Info("BENCHMARK: %d processes make %d calls to %d processes", np, np*start.n, len(pongs))
+	}
+	rc.WaitReady() // created monitor on the event
+
+	rc.Expect(np)
+	if err := nodeping.SendEvent(EVENT.Name, token, gen.MessageOptions{}, start); err != nil {
+		return err
+	}
+	rc.WaitReady() // received event and started calling
+
+	b.StartTimer()
+	rc.Wait()
+	elapsed := b.StopTimer()
+
+	calls := int(rc.Counter(counterSent).Load())
+	b.SetOps(calls)
+	b.ReportMetric("throughput", float64(calls)/elapsed.Seconds(), "calls/sec")
+	b.ReportMetric("timeouts", float64(rc.Counter(counterTimeouts).Load()), "calls")
+	b.ReportLatency("latency", rc.Latency())
+	return nil
+}
@@ -0,0 +1,60 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"harness"
+)
+
+func TestCall(t *testing.T) {
+	for _, name := range []string{"ping/call-local-11", "ping/call-local-NN", "ping/call-network-11", "ping/call-network-NN"} {
+		t.Run(name, func(t *testing.T) {
+			params := harness.Params{"calls": 200}
+			callers := 1
+			if name == "ping/call-local-NN" || name == "ping/call-network-NN" {
+				callers = 4
+				params["callers"] = callers
+				params["pongs"] = 2
+			}
+
+			r, err := harness.RunOnce(name, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Ops != 200*callers {
+				t.Fatalf("expected %d calls, got %d", 200*callers, r.Ops)
+			}
+			if m, found := r.Metric("timeouts"); found == false || m.Value != 0 {
+				t.Fatalf("expected no timeouts, got %.0f", m.Value)
+			}
+			if m, _ := r.Metric("latency max"); m.Value <= 0 {
+				t.Fatalf("expected the call latency to be recorded")
+			}
+		})
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	// the 50th and the 100th calls are answered after 1.5s, so they time out
+	// in 1s, the 51st one waits behind the 50th and makes it in time
+	r, err := harness.RunOnce("ping/call-local-11", harness.Params{
+		"calls":   100,
+		"timeout": 1,
+		"slow":    50,
+		"delay":   1500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, found := r.Metric("timeouts"); found == false || m.Value != 2 {
+		t.Fatalf("expected 2 timeouts, got %.0f", m.Value)
+	}
+	// the timed-out calls are in neither the throughput nor the latency
+	if r.Ops != 98 {
+		t.Fatalf("expected 98 successful calls, got %d", r.Ops)
+	}
+	if m, found := r.Metric("latency max"); found == false || m.Value >= float64(time.Second) {
+		t.Fatalf("expected the latency of the successful calls below 1s, got %s", time.Duration(m.Value))
+	}
+}
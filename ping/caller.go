@@ -0,0 +1,98 @@
+package ping
+
+import (
+	"time"
+
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+func factory_caller() gen.ProcessBehavior {
+	return &caller{}
+}
+
+type caller struct {
+	act.Actor
+
+	pair gen.PID
+	rc   *harness.RunContext
+}
+
+func (c *caller) Init(args ...any) error {
+	c.pair = args[0].(gen.PID)
+	c.rc = args[1].(*harness.RunContext)
+	c.Send(c.PID(), "")
+	return nil
+}
+
+func (c *caller) HandleMessage(from gen.PID, message any) error {
+	if _, err := c.MonitorEvent(EVENT); err != nil {
+		return err
+	}
+	c.rc.Ready()
+	return nil
+}
+
+func (c *caller) HandleEvent(message gen.MessageEvent) error {
+	switch m := message.Message.(type) {
+	case startCall:
+		c.rc.Add(1)
+		c.rc.Ready()
+		c.call(m)
+		c.rc.Done()
+
+	default:
+		c.Log().Warning("unknown event: %#v", message)
+	}
+
+	return nil
+}
+
+// call makes synchronous calls one after another. Every m.slow-th call asks
+// the pong to answer after m.delay, so the calls following it wait in the
+// pong's mailbox and may time out as well.
+func (c *caller) call(m startCall) {
+	latency := harness.NewHistogram()
+
+	var deadline time.Time
+	if m.duration > 0 {
+		deadline = time.Now().Add(m.duration)
+	}
+
+	calls := 0
+	timeouts := 0
+	for i := 1; ; i++ {
+		if deadline.IsZero() {
+			if i > m.n {
+				break
+			}
+		} else if time.Now().After(deadline) {
+			break
+		}
+
+		request := callRequest{}
+		if m.slow > 0 && i%m.slow == 0 {
+			request.Delay = int64(m.delay)
+		}
+
+		start := time.Now()
+		_, err := c.CallWithTimeout(c.pair, request, m.timeout)
+		if err == gen.ErrTimeout {
+			// counted apart, so the throughput and the latency are of the
+			// successful calls only
+			timeouts++
+			continue
+		}
+		if err != nil {
+			c.Log().Error("call failed: %s", err)
+			break
+		}
+		calls++
+		latency.Record(time.Since(start))
+	}
+
+	c.rc.Counter(counterSent).Add(int64(calls))
+	c.rc.Counter(counterTimeouts).Add(int64(timeouts))
+	c.rc.RecordLatency(latency)
+}
@@ -0,0 +1,67 @@
+package ping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ergo.services/ergo/gen"
+	"ergo.services/ergo/lib"
+	"ergo.services/ergo/net/edf"
+)
+
+// ComplexStructValue is the same struct the serial benchmarks encode. It's
+// registered in EDF, so it shows the cost of a typical application message.
+type ComplexStructValue struct {
+	Name      string
+	Id        int32
+	Tags      []string
+	Metadata  map[string]string
+	Pid       gen.PID
+	ProcessId gen.ProcessID
+}
+
+func init() {
+	if err := edf.RegisterTypeOf(ComplexStructValue{}); err != nil {
+		panic(err)
+	}
+}
+
+// payloads are the messages the ping processes can send, selected by
+// the "payload" parameter.
+var payloads = map[string]any{
+	"int":      1,
+	"string64": strings.Repeat("x", 64),
+	"bytes1k":  make([]byte, 1<<10),
+	"bytes64k": make([]byte, 64<<10),
+	"bytes1m":  make([]byte, 1<<20),
+	"struct": ComplexStructValue{
+		Name:      "test",
+		Id:        123,
+		Tags:      []string{"tag1", "tag2"},
+		Metadata:  map[string]string{"key1": "value1", "key2": "value2"},
+		Pid:       gen.PID{Node: "node1", ID: 1, Creation: 1},
+		ProcessId: gen.ProcessID{Node: "node1", Name: "process1"},
+	},
+}
+
+func payloadNames() string {
+	names := make([]string, 0, len(payloads))
+	for name := range payloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// payloadSize returns the size of the payload encoded with EDF. This is
+// the size of the message on the wire except for the framing.
+func payloadSize(payload any) (int, error) {
+	buf := lib.TakeBuffer()
+	defer lib.ReleaseBuffer(buf)
+
+	if err := edf.Encode(payload, buf, edf.Options{}); err != nil {
+		return 0, fmt.Errorf("unable to encode payload: %w", err)
+	}
+	return len(buf.B), nil
+}
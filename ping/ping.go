@@ -0,0 +1,246 @@
+package ping
+
+import (
+	"math"
+	"time"
+
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+func factory_ping() gen.ProcessBehavior {
+	return &ping{}
+}
+
+type ping struct {
+	act.Actor
+
+	pair    gen.PID
+	address string
+	to      any // the pong addressed by pid, name or alias
+	rc      *harness.RunContext
+
+	// round-trip and rate modes
+	mode     string
+	base     time.Time
+	deadline time.Time
+	latency  *harness.Histogram
+	left     int
+	inflight int
+	received int
+
+	// rate mode
+	interval time.Duration
+	sent     int
+}
+
+func (p *ping) Init(args ...any) error {
+	p.pair = args[0].(gen.PID)
+	p.rc = args[1].(*harness.RunContext)
+	p.address = args[2].(string)
+	p.Send(p.PID(), "")
+	return nil
+}
+
+func (p *ping) HandleMessage(from gen.PID, message any) error {
+	switch m := message.(type) {
+	case roundTrip:
+		p.handleReply(m.Sent)
+		return nil
+	case tick:
+		p.sendDue()
+		return nil
+	case flush:
+		// the pong received all the messages
+		p.rc.Done()
+		return nil
+	}
+
+	to, err := p.resolve(p.address)
+	if err != nil {
+		return err
+	}
+	p.to = to
+	if _, err := p.MonitorEvent(EVENT); err != nil {
+		return err
+	}
+	p.rc.Ready()
+	return nil
+}
+
+func (p *ping) HandleEvent(message gen.MessageEvent) error {
+	switch m := message.Message.(type) {
+	case startSend:
+		if m.mode == modeRTT {
+			p.startRoundTrip(m)
+			break
+		}
+		if m.mode == modeRate {
+			p.startRate(m)
+			break
+		}
+		if m.duration > 0 {
+			p.sendFor(m)
+			break
+		}
+
+		p.rc.Add(1)
+		p.rc.Ready()
+		for i := 0; i < m.n; i++ {
+			p.sendPair(m.payload)
+		}
+		p.rc.Counter(counterSent).Add(int64(m.n))
+		p.sendPair(flush{Sent: int64(m.n)})
+
+	default:
+		p.Log().Warning("unknown event: %#v", message)
+	}
+
+	return nil
+}
+
+// sendFor sends messages in batches until the time is up.
+func (p *ping) sendFor(m startSend) {
+	const batch = 1000
+
+	p.rc.Add(1)
+	p.rc.Ready()
+
+	sent := 0
+	deadline := time.Now().Add(m.duration)
+	for time.Now().Before(deadline) {
+		for i := 0; i < batch; i++ {
+			p.sendPair(m.payload)
+		}
+		sent += batch
+	}
+	p.rc.Counter(counterSent).Add(int64(sent))
+	p.sendPair(flush{Sent: int64(sent)})
+}
+
+// startRoundTrip sends the first window of messages. Every message carries
+// the time it was sent at, the pong sends it back and the ping records the
+// round-trip time and sends the next one.
+func (p *ping) startRoundTrip(m startSend) {
+	p.mode = m.mode
+	p.latency = harness.NewHistogram()
+	p.left = m.n
+	if m.duration > 0 {
+		p.left = math.MaxInt
+		p.deadline = time.Now().Add(m.duration)
+	}
+
+	p.rc.Add(1)
+	p.rc.Ready()
+
+	p.base = time.Now()
+	for i := 0; i < m.window && p.left > 0; i++ {
+		p.sendNext()
+	}
+}
+
+func (p *ping) sendNext() {
+	p.left--
+	p.inflight++
+	p.sendPair(roundTrip{Sent: int64(time.Since(p.base))})
+}
+
+func (p *ping) handleReply(sent int64) {
+	p.latency.Record(time.Since(p.base) - time.Duration(sent))
+	p.inflight--
+	p.received++
+
+	if p.mode == modeRate {
+		// the messages are sent on schedule, not on reply
+		if p.left == 0 && p.inflight == 0 {
+			p.finish()
+		}
+		return
+	}
+
+	if p.left > 0 && (p.deadline.IsZero() || time.Now().Before(p.deadline)) {
+		p.sendNext()
+		return
+	}
+	if p.inflight > 0 {
+		return
+	}
+	p.finish()
+}
+
+// finish reports the results once all the replies are received.
+func (p *ping) finish() {
+	p.rc.Counter(counterSent).Add(int64(p.received))
+	p.rc.RecordLatency(p.latency)
+	p.rc.Done()
+}
+
+// startRate starts sending the messages at the fixed rate (open loop): the
+// message i is scheduled at i*interval since the start no matter how fast
+// the replies come back.
+func (p *ping) startRate(m startSend) {
+	p.mode = m.mode
+	p.latency = harness.NewHistogram()
+	p.interval = time.Duration(float64(time.Second) / m.rate)
+	if p.interval < 1 {
+		p.interval = 1
+	}
+	p.left = m.n
+	if m.duration > 0 {
+		p.left = int(m.duration / p.interval)
+	}
+
+	p.rc.Add(1)
+	p.rc.Ready()
+
+	p.base = time.Now()
+	p.sendDue()
+}
+
+// sendDue sends the messages whose scheduled time has come. Every message
+// carries the time it was scheduled at rather than the time it was actually
+// sent, so if the ping falls behind the schedule (the node is overloaded),
+// the delay is counted in the latency instead of being hidden by sending
+// fewer messages (coordinated omission).
+func (p *ping) sendDue() {
+	now := time.Since(p.base)
+	for {
+		at, due := p.next(now)
+		if due == false {
+			break
+		}
+		p.inflight++
+		p.sendPair(roundTrip{Sent: int64(at)})
+	}
+
+	if p.left == 0 {
+		if p.inflight == 0 {
+			p.finish()
+		}
+		return
+	}
+
+	// the replies received in the meantime are handled before the tick
+	wait := time.Duration(p.sent)*p.interval - time.Since(p.base)
+	if wait < time.Millisecond {
+		p.Send(p.PID(), tick{})
+		return
+	}
+	p.SendAfter(p.PID(), tick{}, wait)
+}
+
+// next returns the time the next message is scheduled at (since the start) if
+// it's due by now and counts it as sent.
+func (p *ping) next(now time.Duration) (time.Duration, bool) {
+	if p.left == 0 {
+		return 0, false
+	}
+	at := time.Duration(p.sent) * p.interval
+	if at > now {
+		return 0, false
+	}
+	p.left--
+	p.sent++
+	return at, true
+}
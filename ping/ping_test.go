@@ -0,0 +1,76 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"harness"
+)
+
+func TestRateSchedule(t *testing.T) {
+	p := &ping{interval: time.Millisecond, left: 100}
+
+	due := func(now time.Duration) []time.Duration {
+		var list []time.Duration
+		for {
+			at, ok := p.next(now)
+			if ok == false {
+				return list
+			}
+			list = append(list, at)
+		}
+	}
+
+	if list := due(0); len(list) != 1 || list[0] != 0 {
+		t.Fatalf("expected the first message due at the start, got %v", list)
+	}
+	if list := due(500 * time.Microsecond); len(list) != 0 {
+		t.Fatalf("expected no messages due before the interval, got %v", list)
+	}
+
+	// the ping has fallen behind the schedule: the messages missed meanwhile
+	// are sent at once, but carry the time they were scheduled at, so the
+	// delay is counted in their latency (no coordinated omission)
+	list := due(10*time.Millisecond + 500*time.Microsecond)
+	if len(list) != 10 {
+		t.Fatalf("expected 10 messages due after the stall, got %d", len(list))
+	}
+	for i, at := range list {
+		if expected := time.Duration(i+1) * time.Millisecond; at != expected {
+			t.Fatalf("expected message %d scheduled at %s, got %s", i+1, expected, at)
+		}
+	}
+
+	if list := due(time.Second); len(list) != 89 || p.left != 0 {
+		t.Fatalf("expected the remaining 89 messages due, got %d (%d left)", len(list), p.left)
+	}
+	if _, ok := p.next(2 * time.Second); ok {
+		t.Fatalf("expected no messages due once all of them are sent")
+	}
+}
+
+func TestRateMode(t *testing.T) {
+	// 2 pings at 500 msg/sec each, the last of 200 messages is due in 398ms
+	r, err := harness.RunOnce("ping/local-NN", harness.Params{
+		"messages": 200,
+		"pings":    2,
+		"pongs":    2,
+		"mode":     modeRate,
+		"rate":     1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 400 {
+		t.Fatalf("expected 400 round trips, got %d", r.Ops)
+	}
+	if r.Elapsed < 398*time.Millisecond {
+		t.Fatalf("expected the messages sent on schedule within 398ms at least, got %s", r.Elapsed)
+	}
+	if m, found := r.Metric("target rate"); found == false || m.Value != 1000 {
+		t.Fatalf("expected target rate 1000, got %.0f", m.Value)
+	}
+	if m, _ := r.Metric("latency max"); m.Value <= 0 {
+		t.Fatalf("expected the latency to be recorded")
+	}
+}
@@ -1,58 +1,268 @@
-package main
+package ping
 
 import (
 	"fmt"
 	"runtime"
-	"sync"
 	"time"
 
 	"ergo.services/ergo/gen"
+	"ergo.services/ergo/net/edf"
+	"harness"
 )
 
+const (
+	// ping sends messages as fast as it can, pong doesn't reply
+	modeSend = "send"
+	// pong replies to every message, ping measures the round-trip time
+	modeRTT = "rtt"
+	// ping sends messages at the fixed rate regardless of the replies (open
+	// loop), pong replies, ping measures the latency
+	modeRate = "rate"
+)
+
+// roundTrip is sent in the rtt and rate modes. It carries the time the message
+// was sent at (relative to the ping's start), so the ping can measure the
+// round-trip time once the pong sends it back. In the rate mode it's the time
+// the message was scheduled at.
+type roundTrip struct {
+	Sent int64
+}
+
+// tick makes the ping send the messages that are due in the rate mode
+type tick struct{}
+
+// flush follows the messages sent by the ping. The pong sends it back, and
+// since the messages are delivered in order, the reply means all the messages
+// sent before are received. So the pong doesn't need to share any state with
+// the ping and can run on any node, even in another OS process.
+type flush struct {
+	Sent int64
+}
+
+func init() {
+	// Register types for network transmission
+	if err := edf.RegisterTypeOf(roundTrip{}); err != nil {
+		panic(err)
+	}
+	if err := edf.RegisterTypeOf(flush{}); err != nil {
+		panic(err)
+	}
+	if err := edf.RegisterTypeOf(callRequest{}); err != nil {
+		panic(err)
+	}
+}
+
 type startSend struct {
-	n int
+	n        int
+	duration time.Duration
+	mode     string
+	window   int
+	rate     float64
+	payload  any
 }
 
+const (
+	// counters of the run context
+	counterSent     = "sent"
+	counterTimeouts = "timeouts"
+)
+
 var (
-	WGready sync.WaitGroup
-	WG      sync.WaitGroup
-	EVENT   gen.Event = gen.Event{Name: "send"}
-	NCPU    int       = runtime.NumCPU()
+	EVENT gen.Event = gen.Event{Name: "send"}
+	NCPU  int       = runtime.NumCPU()
 )
 
-func main() {
-	// f, err := os.Create("profile.prof")
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// defer f.Close()
-	//
-	// // Start CPU profiling
-	// if err := pprof.StartCPUProfile(f); err != nil {
-	// 	panic(err)
-	// }
-	// defer pprof.StopCPUProfile()
-	//
-	// // Start tracing
-	// traceFile, err := os.Create("trace.out")
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// defer traceFile.Close()
-	//
-	// if err := trace.Start(traceFile); err != nil {
-	// 	panic(err)
-	// }
-	// defer trace.Stop()
-
-	runTestLocal11()
-	fmt.Println("")
-	time.Sleep(time.Second * 10)
-	runTestLocalNN()
-	fmt.Println("")
-	time.Sleep(time.Second * 10)
-	runTestNetwork11()
-	fmt.Println("")
-	time.Sleep(time.Second * 10)
-	runTestNetworkNN()
+// pingParams returns the parameters of a ping scenario with the given defaults.
+func pingParams(messages int, processes int) []harness.Param {
+	return []harness.Param{
+		{Name: "messages", Usage: "number of messages sent by each ping process", Default: messages},
+		{Name: "pings", Usage: "number of ping processes", Default: processes},
+		{Name: "pongs", Usage: "number of pong processes, the ping processes are distributed among them evenly", Default: processes},
+		{Name: "duration", Usage: "send messages for the given time instead of the fixed number of messages", Default: time.Duration(0)},
+		{Name: "mode", Usage: "send (fire-and-forget), rtt (pong replies, ping measures the round-trip time) or rate (open loop: rtt at the fixed rate)", Default: modeSend},
+		{Name: "window", Usage: "number of messages in flight per ping process in the rtt mode", Default: 1},
+		{Name: "rate", Usage: "messages per second sent by all the ping processes together in the rate mode", Default: 100_000},
+		{Name: "payload", Usage: "message sent in the send mode: " + payloadNames(), Default: "int"},
+		{Name: "address", Usage: "how the ping processes address the pong processes: pid, name (registered name) or alias", Default: addressPID},
+		{Name: "gomaxprocs", Usage: "GOMAXPROCS of the ping node process (0 keeps the default)", Default: 0},
+	}
+}
+
+// setGOMAXPROCS applies the "gomaxprocs" parameter. It goes before the nodes
+// are started, so they are set up for the given GOMAXPROCS.
+func setGOMAXPROCS(b *harness.B) {
+	if procs := b.Int("gomaxprocs"); procs > 0 {
+		b.SetGOMAXPROCS(procs)
+	}
+}
+
+// poolSize returns the size of the connection pool of the N-N network
+// scenarios, both ends of the link use it. It's half of GOMAXPROCS, so it
+// goes after setGOMAXPROCS.
+func poolSize() int {
+	return runtime.GOMAXPROCS(0) / 2
+}
+
+// startLocal starts the node for the local scenarios and spawns the pong
+// processes on it.
+func startLocal(b *harness.B, name gen.Atom) (gen.Node, []gen.PID, error) {
+	nodeping, err := b.StartNode(name, gen.NodeOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pongs, err := spawnPongs(b, func() (gen.PID, error) {
+		return nodeping.Spawn(factory_pong, gen.ProcessOptions{})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodeping, pongs, nil
+}
+
+// startNetwork starts two nodes for the network scenarios, connects them and
+// spawns the pong processes on the second one.
+func startNetwork(b *harness.B, name1, name2 gen.Atom, options gen.NodeOptions) (gen.Node, []gen.PID, error) {
+	nodeping, err := b.StartNode(name1, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodepong, err := b.StartNode(name2, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pong := gen.Atom("pong")
+	nodepong.Network().EnableSpawn(pong, factory_pong)
+
+	remote, err := nodeping.Network().GetNode(nodepong.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pongs, err := spawnPongs(b, func() (gen.PID, error) {
+		return remote.Spawn(pong, gen.ProcessOptions{})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodeping, pongs, nil
+}
+
+// spawnPongs starts the number of pong processes set by the "pongs" parameter
+// using the given spawn function.
+func spawnPongs(b *harness.B, spawn func() (gen.PID, error)) ([]gen.PID, error) {
+	n := b.Int("pongs")
+	if n < 1 {
+		return nil, fmt.Errorf("number of pong processes must be positive")
+	}
+
+	pongs := make([]gen.PID, n)
+	for i := range pongs {
+		pid, err := spawn()
+		if err != nil {
+			return nil, err
+		}
+		pongs[i] = pid
+	}
+	return pongs, nil
+}
+
+// runPing starts the ping processes on the node, makes them send messages to
+// the pong processes and waits until all the messages are received.
+func runPing(b *harness.B, nodeping gen.Node, pongs []gen.PID) error {
+	N := b.Int("messages")
+	np := b.Int("pings")
+	duration := b.Duration("duration")
+	mode := b.String("mode")
+	window := b.Int("window")
+	rate := b.Int("rate")
+	address := b.String("address")
+	if np < 1 {
+		return fmt.Errorf("number of ping processes must be positive")
+	}
+	if N < 1 && duration == 0 {
+		return fmt.Errorf("number of messages must be positive")
+	}
+	if mode != modeSend && mode != modeRTT && mode != modeRate {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	if window < 1 {
+		return fmt.Errorf("window must be positive")
+	}
+	if rate < 1 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if address != addressPID && address != addressName && address != addressAlias {
+		return fmt.Errorf("unknown address %q (expected pid, name or alias)", address)
+	}
+	payload, found := payloads[b.String("payload")]
+	if found == false {
+		return fmt.Errorf("unknown payload %q (available: %s)", b.String("payload"), payloadNames())
+	}
+	size, err := payloadSize(payload)
+	if err != nil {
+		return err
+	}
+	token, err := nodeping.RegisterEvent(EVENT.Name, gen.EventOptions{})
+	if err != nil {
+		return err
+	}
+
+	// starting ping processes
+	rc := harness.NewRunContext()
+	rc.Expect(np)
+	for i := 0; i < np; i++ {
+		if _, err := nodeping.Spawn(factory_ping, gen.ProcessOptions{}, pongs[i%len(pongs)], rc, address); err != nil {
+			return err
+		}
+	}
+	if duration > 0 {
+		nodeping.Log().Info("BENCHMARK: %d processes send messages to %d processes for %s", np, len(pongs), duration)
+	} else {
+		nodeping.Log().Info("BENCHMARK: %d processes send %d messages to %d processes", np, np*N, len(pongs))
+	}
+	if mode == modeRate {
+		nodeping.Log().Info("BENCHMARK: target rate %d msg/sec", rate)
+	}
+	if address != addressPID {
+		nodeping.Log().Info("BENCHMARK: the pong processes are addressed by %s", address)
+	}
+	rc.WaitReady() // created monitor on the event
+
+	rc.Expect(np)
+	start := startSend{
+		n:        N,
+		duration: duration,
+		mode:     mode,
+		window:   window,
+		rate:     float64(rate) / float64(np),
+		payload:  payload,
+	}
+	if err := nodeping.SendEvent(EVENT.Name, token, gen.MessageOptions{}, start); err != nil {
+		return err
+	}
+	rc.WaitReady() // received event and started sending
+
+	b.StartTimer()
+	rc.Wait()
+	elapsed := b.StopTimer()
+
+	sent := int(rc.Counter(counterSent).Load())
+	b.SetOps(sent)
+	switch mode {
+	case modeRTT:
+		b.ReportMetric("throughput", float64(sent)/elapsed.Seconds(), "rtt/sec")
+		b.ReportLatency("latency", rc.Latency())
+		return nil
+	case modeRate:
+		b.ReportMetric("target rate", float64(rate), "msg/sec")
+		b.ReportMetric("throughput", float64(sent)/elapsed.Seconds(), "rtt/sec")
+		b.ReportLatency("latency", rc.Latency())
+		return nil
+	}
+	b.ReportMetric("throughput", float64(sent)/elapsed.Seconds(), "msg/sec")
+	b.ReportMetric("bandwidth", float64(sent*size)/elapsed.Seconds(), "B/sec")
+	b.ReportMetric("payload size", float64(size), "B")
+	return nil
 }
@@ -0,0 +1,59 @@
+package ping
+
+import (
+	"runtime"
+	"testing"
+
+	"harness"
+)
+
+func TestPing(t *testing.T) {
+	for _, name := range []string{"ping/local-11", "ping/local-NN", "ping/network-11", "ping/network-NN"} {
+		for _, mode := range []string{modeSend, modeRTT} {
+			t.Run(name+"/"+mode, func(t *testing.T) {
+				params := harness.Params{"messages": 300, "mode": mode}
+				pings := 1
+				if name == "ping/local-NN" || name == "ping/network-NN" {
+					pings = 4
+					params["pings"] = pings
+					params["pongs"] = 2
+				}
+
+				r, err := harness.RunOnce(name, params)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Ops != 300*pings {
+					t.Fatalf("expected %d messages, got %d", 300*pings, r.Ops)
+				}
+				if m, found := r.Metric("throughput"); found == false || m.Value <= 0 {
+					t.Fatalf("expected positive throughput, got %f", m.Value)
+				}
+				if m, _ := r.Metric("latency max"); mode == modeRTT && m.Value <= 0 {
+					t.Fatalf("expected the round-trip latency to be recorded")
+				}
+			})
+		}
+	}
+}
+
+func TestPingGOMAXPROCS(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	for _, name := range []string{"ping/local-NN", "ping/network-NN"} {
+		t.Run(name, func(t *testing.T) {
+			r, err := harness.RunOnce(name, harness.Params{"messages": 200, "pings": 2, "pongs": 2, "gomaxprocs": 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Env.GOMAXPROCS != 1 {
+				t.Fatalf("expected GOMAXPROCS 1 in the result, got %d", r.Env.GOMAXPROCS)
+			}
+			if r.Ops != 400 {
+				t.Fatalf("expected 400 messages, got %d", r.Ops)
+			}
+			if n := runtime.GOMAXPROCS(0); n != procs {
+				t.Fatalf("expected GOMAXPROCS %d restored, got %d", procs, n)
+			}
+		})
+	}
+}
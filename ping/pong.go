@@ -1,6 +1,8 @@
-package main
+package ping
 
 import (
+	"time"
+
 	"ergo.services/ergo/act"
 	"ergo.services/ergo/gen"
 )
@@ -11,9 +13,31 @@ func factory_pong() gen.ProcessBehavior {
 
 type pong struct {
 	act.Actor
+
+	// registered on request of the ping
+	name  gen.Atom
+	alias gen.Alias
 }
 
 func (p *pong) HandleMessage(from gen.PID, message any) error {
-	WG.Done()
+	switch m := message.(type) {
+	case roundTrip:
+		// round-trip mode: send it back to the ping
+		return p.SendPID(from, m)
+	case flush:
+		// all the messages sent before are received
+		return p.SendPID(from, m)
+	}
 	return nil
 }
+
+func (p *pong) HandleCall(from gen.PID, ref gen.Ref, request any) (any, error) {
+	if r, ok := request.(addressRequest); ok {
+		return p.address(r.Kind)
+	}
+	if r, ok := request.(callRequest); ok && r.Delay > 0 {
+		// slow responder
+		time.Sleep(time.Duration(r.Delay))
+	}
+	return request, nil
+}
@@ -0,0 +1,298 @@
+package ping
+
+import (
+	"fmt"
+	"time"
+
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"ergo.services/ergo/net/edf"
+	"harness"
+)
+
+const (
+	// counters of the run context: the flood messages sent so far and
+	// the ones overtaken by all the probes
+	counterFlooded   = "flooded"
+	counterOvertaken = "overtaken"
+)
+
+// priorities maps the values of the "priority" parameter to the message
+// priorities. The high priority messages go to the system queue of the
+// mailbox, the max priority ones to the urgent queue, both are handled
+// before the main queue.
+var priorities = map[string]gen.MessagePriority{
+	"normal": gen.MessagePriorityNormal,
+	"high":   gen.MessagePriorityHigh,
+	"max":    gen.MessagePriorityMax,
+}
+
+// probe is sent with the priority while the main queue of the target is
+// flooded.
+type probe struct {
+	Seq  int
+	Sent int64
+}
+
+// probeReply is sent back by the target. Handled is the number of the flood
+// messages the target has handled before the probe.
+type probeReply struct {
+	Seq     int
+	Sent    int64
+	Handled int64
+}
+
+func init() {
+	if err := edf.RegisterTypeOf(probe{}); err != nil {
+		panic(err)
+	}
+	if err := edf.RegisterTypeOf(probeReply{}); err != nil {
+		panic(err)
+	}
+}
+
+type startFlood struct {
+	backlog int
+}
+
+type startProbe struct {
+	probes   int
+	interval time.Duration
+	priority gen.MessagePriority
+}
+
+// priorityParams returns the parameters of a priority scenario.
+func priorityParams() []harness.Param {
+	return []harness.Param{
+		{Name: "flooders", Usage: "number of processes flooding the main queue of the target", Default: 4},
+		{Name: "backlog", Usage: "number of messages sent by each flooder", Default: 1_000_000},
+		{Name: "probes", Usage: "number of messages sent with the priority", Default: 100},
+		{Name: "interval", Usage: "interval between the priority messages", Default: time.Millisecond},
+		{Name: "priority", Usage: "priority of the probe messages: normal, high (system queue) or max (urgent queue)", Default: "high"},
+	}
+}
+
+// runPriority floods the main queue of the target with messages and sends
+// the probe messages with the priority meanwhile. The target replies to every
+// probe with the number of the flood messages handled before it, so the prober
+// measures how long the probe took and how many flood messages it overtook.
+func runPriority(b *harness.B, nodeping gen.Node, target gen.PID) error {
+	nf := b.Int("flooders")
+	flood := startFlood{backlog: b.Int("backlog")}
+	start := startProbe{
+		probes:   b.Int("probes"),
+		interval: b.Duration("interval"),
+	}
+	if nf < 1 {
+		return fmt.Errorf("number of flooders must be positive")
+	}
+	if flood.backlog < 1 {
+		return fmt.Errorf("backlog must be positive")
+	}
+	if start.probes < 1 {
+		return fmt.Errorf("number of probes must be positive")
+	}
+	priority, found := priorities[b.String("priority")]
+	if found == false {
+		return fmt.Errorf("unknown priority %q (expected normal, high or max)", b.String("priority"))
+	}
+	start.priority = priority
+
+	token, err := nodeping.RegisterEvent(EVENT.Name, gen.EventOptions{})
+	if err != nil {
+		return err
+	}
+
+	rc := harness.NewRunContext()
+	rc.Expect(nf + 1)
+	for i := 0; i < nf; i++ {
+		if _, err := nodeping.Spawn(factory_flooder, gen.ProcessOptions{}, target, rc); err != nil {
+			return err
+		}
+	}
+	if _, err := nodeping.Spawn(factory_prober, gen.ProcessOptions{}, target, rc); err != nil {
+		return err
+	}
+	nodeping.Log().Info("BENCHMARK: %d processes send %d messages to 1 process, %d messages with the %s priority overtake them",
+		nf, nf*flood.backlog, start.probes, b.String("priority"))
+	rc.WaitReady() // created monitor on the event
+
+	rc.Expect(nf + 1)
+	if err := nodeping.SendEvent(EVENT.Name, token, gen.MessageOptions{}, flood); err != nil {
+		return err
+	}
+	if err := nodeping.SendEvent(EVENT.Name, token, gen.MessageOptions{}, start); err != nil {
+		return err
+	}
+	rc.WaitReady() // received event and started sending
+
+	b.StartTimer()
+	rc.Wait()
+	elapsed := b.StopTimer()
+
+	flooded := rc.Counter(counterFlooded).Load()
+	b.SetOps(start.probes)
+	b.ReportLatency("probe latency", rc.Latency())
+	b.ReportMetric("overtaken", float64(rc.Counter(counterOvertaken).Load())/float64(start.probes), "msg")
+	b.ReportMetric("flood throughput", float64(flooded)/elapsed.Seconds(), "msg/sec")
+	return nil
+}
+
+// flooder sends the backlog of messages to the target as fast as it can.
+func factory_flooder() gen.ProcessBehavior {
+	return &flooder{}
+}
+
+type flooder struct {
+	act.Actor
+
+	target gen.PID
+	rc     *harness.RunContext
+}
+
+func (f *flooder) Init(args ...any) error {
+	f.target = args[0].(gen.PID)
+	f.rc = args[1].(*harness.RunContext)
+	f.Send(f.PID(), "")
+	return nil
+}
+
+func (f *flooder) HandleMessage(from gen.PID, message any) error {
+	if _, ok := message.(flush); ok {
+		// the target received all the messages
+		f.rc.Done()
+		return nil
+	}
+
+	if _, err := f.MonitorEvent(EVENT); err != nil {
+		return err
+	}
+	f.rc.Ready()
+	return nil
+}
+
+func (f *flooder) HandleEvent(message gen.MessageEvent) error {
+	m, ok := message.Message.(startFlood)
+	if ok == false {
+		return nil
+	}
+
+	const batch = 1000
+
+	f.rc.Add(1)
+	f.rc.Ready()
+	for sent := 0; sent < m.backlog; sent += batch {
+		n := min(batch, m.backlog-sent)
+		for i := 0; i < n; i++ {
+			f.SendPID(f.target, i)
+		}
+		f.rc.Counter(counterFlooded).Add(int64(n))
+	}
+	f.SendPID(f.target, flush{Sent: int64(m.backlog)})
+	return nil
+}
+
+// prober sends the probes with the priority at the interval.
+func factory_prober() gen.ProcessBehavior {
+	return &prober{}
+}
+
+type prober struct {
+	act.Actor
+
+	target gen.PID
+	rc     *harness.RunContext
+
+	start    startProbe
+	base     time.Time
+	flooded  []int64 // flood messages sent before the probe
+	latency  *harness.Histogram
+	received int
+}
+
+func (p *prober) Init(args ...any) error {
+	p.target = args[0].(gen.PID)
+	p.rc = args[1].(*harness.RunContext)
+	p.Send(p.PID(), "")
+	return nil
+}
+
+func (p *prober) HandleMessage(from gen.PID, message any) error {
+	switch m := message.(type) {
+	case tick:
+		p.sendProbe()
+		return nil
+	case probeReply:
+		p.handleReply(m)
+		return nil
+	}
+
+	if _, err := p.MonitorEvent(EVENT); err != nil {
+		return err
+	}
+	p.rc.Ready()
+	return nil
+}
+
+func (p *prober) HandleEvent(message gen.MessageEvent) error {
+	m, ok := message.Message.(startProbe)
+	if ok == false {
+		return nil
+	}
+
+	p.start = m
+	p.latency = harness.NewHistogram()
+	p.rc.Add(1)
+	p.rc.Ready()
+
+	// let the backlog grow before the first probe
+	p.base = time.Now()
+	p.SendAfter(p.PID(), tick{}, m.interval)
+	return nil
+}
+
+func (p *prober) sendProbe() {
+	seq := len(p.flooded)
+	p.flooded = append(p.flooded, p.rc.Counter(counterFlooded).Load())
+	p.SendWithPriority(p.target, probe{Seq: seq, Sent: int64(time.Since(p.base))}, p.start.priority)
+
+	if len(p.flooded) < p.start.probes {
+		p.SendAfter(p.PID(), tick{}, p.start.interval)
+	}
+}
+
+func (p *prober) handleReply(m probeReply) {
+	p.latency.Record(time.Since(p.base) - time.Duration(m.Sent))
+	if overtaken := p.flooded[m.Seq] - m.Handled; overtaken > 0 {
+		p.rc.Counter(counterOvertaken).Add(overtaken)
+	}
+
+	p.received++
+	if p.received < p.start.probes {
+		return
+	}
+	p.rc.RecordLatency(p.latency)
+	p.rc.Done()
+}
+
+// target counts the flood messages and replies to the probes.
+func factory_target() gen.ProcessBehavior {
+	return &target{}
+}
+
+type target struct {
+	act.Actor
+
+	handled int64
+}
+
+func (t *target) HandleMessage(from gen.PID, message any) error {
+	switch m := message.(type) {
+	case probe:
+		return t.SendPID(from, probeReply{Seq: m.Seq, Sent: m.Sent, Handled: t.handled})
+	case flush:
+		return t.SendPID(from, m)
+	}
+	t.handled++
+	return nil
+}
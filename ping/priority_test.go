@@ -0,0 +1,46 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"harness"
+)
+
+func TestPriority(t *testing.T) {
+	for _, name := range []string{"ping/priority-local", "ping/priority-network"} {
+		for _, priority := range []string{"normal", "high", "max"} {
+			t.Run(name+"/"+priority, func(t *testing.T) {
+				r, err := harness.RunOnce(name, harness.Params{
+					"flooders": 2,
+					"backlog":  200_000,
+					"probes":   10,
+					"interval": time.Millisecond,
+					"priority": priority,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Ops != 10 {
+					t.Fatalf("expected 10 probes, got %d", r.Ops)
+				}
+
+				// the flood messages are counted once put in the mailbox of
+				// the local target, so the normal probes, queued behind them,
+				// can't overtake any. Over the network the flooders and the
+				// prober may go through the different connections of the pool.
+				m, found := r.Metric("overtaken")
+				if found == false {
+					t.Fatalf("no overtaken metric")
+				}
+				overtaken := m.Value
+				if name == "ping/priority-local" && priority == "normal" && overtaken != 0 {
+					t.Fatalf("expected the normal probes to overtake nothing, got %.2f", overtaken)
+				}
+				if priority != "normal" && overtaken <= 0 {
+					t.Fatalf("expected the %s probes to overtake the flood", priority)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ping
+
+import (
+	"ergo.services/ergo/gen"
+	"ergo.services/ergo/net/handshake"
+	"harness"
+)
+
+func init() {
+	harness.Register(harness.Scenario{
+		Name:        "ping/call-local-11",
+		Description: "1 process makes synchronous calls to 1 process on the same node",
+		Params:      callParams(1_000_000, 1),
+		Run: func(b *harness.B) error {
+			nodeping, pongs, err := startLocal(b, "node_call_local_11@localhost")
+			if err != nil {
+				return err
+			}
+			return runCall(b, nodeping, pongs)
+		},
+	})
+
+	harness.Register(harness.Scenario{
+		Name:        "ping/call-local-NN",
+		Description: "N processes make synchronous calls to N processes on the same node (N = number of CPU)",
+		Params:      callParams(300_000, NCPU),
+		Run: func(b *harness.B) error {
+			nodeping, pongs, err := startLocal(b, "node_call_local_NN@localhost")
+			if err != nil {
+				return err
+			}
+			return runCall(b, nodeping, pongs)
+		},
+	})
+
+	harness.Register(harness.Scenario{
+		Name:        "ping/call-network-11",
+		Description: "1 process makes synchronous calls to 1 process on a remote node",
+		Params:      callParams(300_000, 1),
+		Run: func(b *harness.B) error {
+			nodeping, pongs, err := startNetwork(b,
+				"node_call_network_11_n1@localhost",
+				"node_call_network_11_n2@localhost",
+				gen.NodeOptions{},
+			)
+			if err != nil {
+				return err
+			}
+			return runCall(b, nodeping, pongs)
+		},
+	})
+
+	harness.Register(harness.Scenario{
+		Name:        "ping/call-network-NN",
+		Description: "N processes make synchronous calls to N processes on a remote node (N = number of CPU)",
+		Params:      callParams(100_000, NCPU),
+		Run: func(b *harness.B) error {
+			options := gen.NodeOptions{}
+			a := gen.AcceptorOptions{
+				Handshake: handshake.Create(handshake.Options{PoolSize: poolSize()}),
+			}
+			options.Network.Acceptors = append(options.Network.Acceptors, a)
+
+			nodeping, pongs, err := startNetwork(b,
+				"node_call_network_NN_n1@localhost",
+				"node_call_network_NN_n2@localhost",
+				options,
+			)
+			if err != nil {
+				return err
+			}
+			return runCall(b, nodeping, pongs)
+		},
+	})
+}
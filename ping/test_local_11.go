@@ -1,66 +1,23 @@
-package main
+package ping
 
 import (
-	"runtime"
-	"time"
-
-	"ergo.services/ergo"
-	"ergo.services/ergo/gen"
-	"ergo.services/logger/colored"
-	. "github.com/klauspost/cpuid/v2"
+	"harness"
 )
 
-func runTestLocal11() {
-	N := 3_000_000
-	// prepare node
-	options := gen.NodeOptions{}
-	options.Network.Cookie = "cookie"
-	loggercolored, err := colored.CreateLogger(colored.Options{
-		TimeFormat:    time.DateTime,
-		DisableBanner: true,
+func init() {
+	harness.Register(harness.Scenario{
+		Name:        "ping/local-11",
+		Description: "1 process sends messages to 1 process on the same node",
+		Params:      pingParams(3_000_000, 1),
+		Run:         runTestLocal11,
 	})
-	if err != nil {
-		panic(err)
-	}
-	options.Log.DefaultLogger.Disable = true
-	options.Log.Loggers = append(
-		options.Log.Loggers,
-		gen.Logger{Name: "colored", Logger: loggercolored},
-	)
-
-	nodeping, err := ergo.StartNode("node_local_11@localhost", options)
-	if err != nil {
-		panic(err)
-	}
+}
 
-	token, err := nodeping.RegisterEvent(EVENT.Name, gen.EventOptions{})
+func runTestLocal11(b *harness.B) error {
+	setGOMAXPROCS(b)
+	nodeping, pongs, err := startLocal(b, "node_local_11@localhost")
 	if err != nil {
-		panic(err)
+		return err
 	}
-	nodeping.Log().Info("-------------------------- LOCAL 1-1 (start) ----------------------------------")
-	nodeping.Log().Info("Go Version : %s", runtime.Version())
-	nodeping.Log().Info("CPU: %s (Physical Cores: %d)", CPU.BrandName, CPU.PhysicalCores)
-	nodeping.Log().Info("Runtime CPUs: %d", NCPU)
-
-	// starting 1 ping process
-	WGready.Add(1)
-	if _, err := nodeping.Spawn(factory_ping_local, gen.ProcessOptions{}); err != nil {
-		panic(err)
-	}
-	nodeping.Log().Info("BENCHMARK: 1 process sends %d messages to 1 process", N)
-	WGready.Wait() // created monitor on the event and spawned a pong process
-
-	WGready.Add(1)
-	if err := nodeping.SendEvent(EVENT.Name, token, gen.MessageOptions{}, startSend{n: N}); err != nil {
-		panic(err)
-	}
-	WGready.Wait() // received event and started sending
-
-	start := time.Now()
-	WG.Wait()
-	elapsed := time.Since(start)
-
-	nodeping.Log().Info("received %d messages. %f msg/sec", N, float64(N)/elapsed.Seconds())
-
-	nodeping.Log().Info("-------------------------- LOCAL 1-1 (end) ----------------------------------")
+	return runPing(b, nodeping, pongs)
 }
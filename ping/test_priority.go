@@ -0,0 +1,53 @@
+package ping
+
+import (
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+func init() {
+	harness.Register(harness.Scenario{
+		Name:        "ping/priority-local",
+		Description: "messages with the priority overtake the flood of messages to 1 process on the same node",
+		Params:      priorityParams(),
+		Run: func(b *harness.B) error {
+			nodeping, err := b.StartNode("node_priority_local@localhost", gen.NodeOptions{})
+			if err != nil {
+				return err
+			}
+			target, err := nodeping.Spawn(factory_target, gen.ProcessOptions{})
+			if err != nil {
+				return err
+			}
+			return runPriority(b, nodeping, target)
+		},
+	})
+
+	harness.Register(harness.Scenario{
+		Name:        "ping/priority-network",
+		Description: "messages with the priority overtake the flood of messages to 1 process on a remote node",
+		Params:      priorityParams(),
+		Run: func(b *harness.B) error {
+			nodeping, err := b.StartNode("node_priority_network_n1@localhost", gen.NodeOptions{})
+			if err != nil {
+				return err
+			}
+			nodetarget, err := b.StartNode("node_priority_network_n2@localhost", gen.NodeOptions{})
+			if err != nil {
+				return err
+			}
+
+			name := gen.Atom("target")
+			nodetarget.Network().EnableSpawn(name, factory_target)
+			remote, err := nodeping.Network().GetNode(nodetarget.Name())
+			if err != nil {
+				return err
+			}
+			target, err := remote.Spawn(name, gen.ProcessOptions{})
+			if err != nil {
+				return err
+			}
+			return runPriority(b, nodeping, target)
+		},
+	})
+}
@@ -0,0 +1,166 @@
+package ping
+
+import (
+	"fmt"
+
+	"ergo.services/ergo/gen"
+	"ergo.services/ergo/net/handshake"
+	"harness"
+)
+
+const rolePongNode = "ping/pong-node"
+
+func init() {
+	harness.Register(harness.Scenario{
+		Name:        "ping/process-11",
+		Description: "1 process sends messages to 1 process on a node running in a separate OS process",
+		Params:      processParams(3_000_000, 1),
+		Run:         runTestProcess11,
+	})
+	harness.Register(harness.Scenario{
+		Name:        "ping/process-NN",
+		Description: "N processes send messages to N processes on a node running in a separate OS process (N = number of CPU)",
+		Params:      processParams(1_000_000, NCPU),
+		Run:         runTestProcessNN,
+	})
+	harness.RegisterRole(rolePongNode, runPongNode)
+}
+
+// processParams returns the parameters of the ping scenarios with the pong
+// node in a child process.
+func processParams(messages int, processes int) []harness.Param {
+	return append(pingParams(messages, processes),
+		harness.Param{Name: "cpus", Usage: "pin the ping node to the CPUs, e.g. 0-3 (Linux only)", Default: ""},
+		harness.Param{Name: "pong-cpus", Usage: "pin the pong node to the CPUs, e.g. 4-7 (Linux only)", Default: ""},
+	)
+}
+
+// pongNode is sent to the child process to start the pong node
+type pongNode struct {
+	Name     gen.Atom
+	PoolSize int
+	CPUs     string
+}
+
+func runTestProcess11(b *harness.B) error {
+	if err := setCPUs(b); err != nil {
+		return err
+	}
+	nodeping, pongs, err := startProcess(b,
+		"node_process_11_n1@localhost",
+		pongNode{Name: "node_process_11_n2@localhost"},
+		gen.NodeOptions{},
+	)
+	if err != nil {
+		return err
+	}
+	return runPing(b, nodeping, pongs)
+}
+
+func runTestProcessNN(b *harness.B) error {
+	if err := setCPUs(b); err != nil {
+		return err
+	}
+	pool := poolSize()
+	options := gen.NodeOptions{}
+	a := gen.AcceptorOptions{
+		Handshake: handshake.Create(handshake.Options{PoolSize: pool}),
+	}
+	options.Network.Acceptors = append(options.Network.Acceptors, a)
+
+	nodeping, pongs, err := startProcess(b,
+		"node_process_NN_n1@localhost",
+		pongNode{Name: "node_process_NN_n2@localhost", PoolSize: pool},
+		options,
+	)
+	if err != nil {
+		return err
+	}
+	return runPing(b, nodeping, pongs)
+}
+
+// setCPUs pins the ping node to the CPUs and applies the "gomaxprocs"
+// parameter on top of it, before the nodes are started.
+func setCPUs(b *harness.B) error {
+	if cpus := b.String("cpus"); cpus != "" {
+		if err := b.SetAffinity(cpus); err != nil {
+			return err
+		}
+	}
+	setGOMAXPROCS(b)
+	return nil
+}
+
+// startProcess starts the ping node in this process and the pong node in
+// a child one, then spawns the pong processes on the pong node.
+func startProcess(b *harness.B, name gen.Atom, pn pongNode, options gen.NodeOptions) (gen.Node, []gen.PID, error) {
+	pn.CPUs = b.String("pong-cpus")
+
+	// the ping node goes first, so the pong node registers on its registrar
+	nodeping, err := b.StartNode(name, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctl, err := b.StartChild(rolePongNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctl.Send(pn); err != nil {
+		return nil, nil, err
+	}
+	var ready string
+	if err := ctl.Receive(&ready); err != nil {
+		return nil, nil, fmt.Errorf("unable to start pong node: %w", err)
+	}
+
+	remote, err := nodeping.Network().GetNode(pn.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pongs, err := spawnPongs(b, func() (gen.PID, error) {
+		return remote.Spawn("pong", gen.ProcessOptions{})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodeping, pongs, nil
+}
+
+// runPongNode runs in the child process. It starts the pong node and keeps
+// it running until the parent closes the control channel.
+func runPongNode(ctl *harness.Control) error {
+	var pn pongNode
+	if err := ctl.Receive(&pn); err != nil {
+		return err
+	}
+
+	if pn.CPUs != "" {
+		if err := harness.SetAffinity(pn.CPUs); err != nil {
+			return err
+		}
+	}
+
+	options := gen.NodeOptions{}
+	if pn.PoolSize > 0 {
+		a := gen.AcceptorOptions{
+			Handshake: handshake.Create(handshake.Options{PoolSize: pn.PoolSize}),
+		}
+		options.Network.Acceptors = append(options.Network.Acceptors, a)
+	}
+
+	nodepong, err := harness.StartNode(pn.Name, options)
+	if err != nil {
+		return err
+	}
+	defer nodepong.Stop()
+	nodepong.Network().EnableSpawn("pong", factory_pong)
+
+	if err := ctl.Send("ready"); err != nil {
+		return err
+	}
+	// wait until the scenario is finished
+	ctl.Receive(nil)
+	return nil
+}
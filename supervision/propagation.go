@@ -0,0 +1,130 @@
+package supervision
+
+import (
+	"fmt"
+	"time"
+
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+const (
+	// how the watchers keep track of the watched process
+	watchLink    = "link"
+	watchMonitor = "monitor"
+)
+
+func init() {
+	for _, s := range []struct {
+		watch string
+		verb  string
+	}{
+		{watchMonitor, "monitor"},
+		{watchLink, "link to"},
+	} {
+		watch := s.watch
+		harness.Register(harness.Scenario{
+			Name:        "propagation/" + watch,
+			Description: fmt.Sprintf("processes on several nodes %s 1 process, it terminates and every one of them is notified", s.verb),
+			Params: []harness.Param{
+				{Name: "watchers", Usage: "number of the watching processes, distributed among the nodes evenly", Default: 100_000},
+				{Name: "nodes", Usage: "number of the nodes of the watching processes (0 runs them on the node of the watched process)", Default: 10},
+				{Name: "timeout", Usage: "time the watchers are given to start watching and to be notified, the run fails after it", Default: time.Minute},
+			},
+			Run: func(b *harness.B) error {
+				return runPropagation(b, watch)
+			},
+		})
+	}
+}
+
+// runPropagation starts the watched process and the watchers linked to or
+// monitoring it, then terminates the watched process and waits until every
+// watcher receives the exit signal or the down message.
+func runPropagation(b *harness.B, watch string) error {
+	nw := b.Int("watchers")
+	nn := b.Int("nodes")
+	timeout := b.Duration("timeout")
+	if nw < 1 {
+		return fmt.Errorf("number of watchers must be positive")
+	}
+	if nn < 0 {
+		return fmt.Errorf("number of nodes must not be negative")
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	target, err := b.StartNode("watched@localhost", gen.NodeOptions{})
+	if err != nil {
+		return err
+	}
+	nodes := []gen.Node{target}
+	if nn > 0 {
+		nodes = make([]gen.Node, nn)
+		for i := range nodes {
+			name := fmt.Sprintf("watcher%d@localhost", i+1)
+			if nodes[i], err = b.StartNode(gen.Atom(name), gen.NodeOptions{}); err != nil {
+				return err
+			}
+			if _, err := nodes[i].Network().GetNode(target.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	pid, err := target.Spawn(factory_watched, gen.ProcessOptions{})
+	if err != nil {
+		return err
+	}
+
+	target.Log().Info("Spawning %d processes on %d nodes (%s)...", nw, len(nodes), watch)
+	rc := harness.NewRunContext()
+	rc.Expect(nw)
+	startWatch := time.Now()
+	for i := 0; i < nw; i++ {
+		if _, err := nodes[i%len(nodes)].Spawn(factory_watcher, gen.ProcessOptions{}, pid, watch, rc); err != nil {
+			return err
+		}
+	}
+	// linked to or monitoring the watched process
+	if err := waitTimeout(rc.WaitReady, timeout); err != nil {
+		return fmt.Errorf("watchers are not ready: %w", err)
+	}
+	watchDuration := time.Since(startWatch)
+
+	target.Log().Info("BENCHMARK: terminating 1 process, %d processes on %d nodes watch it (%s)", nw, len(nodes), watch)
+	rc.Add(nw)
+	b.StartTimer()
+	if err := target.SendExit(pid, gen.TerminateReasonShutdown); err != nil {
+		return err
+	}
+	if err := waitTimeout(rc.Wait, timeout); err != nil {
+		return fmt.Errorf("watchers are not notified: %w", err)
+	}
+	elapsed := b.StopTimer()
+
+	b.SetOps(nw)
+	b.ReportDuration(watch, watchDuration)
+	b.ReportMetric(watch+" rate", float64(nw)/watchDuration.Seconds(), "proc/sec")
+	b.ReportDuration("deliver all", elapsed)
+	b.ReportMetric("delivery rate", float64(nw)/elapsed.Seconds(), "msg/sec")
+	return nil
+}
+
+// waitTimeout calls the wait function and fails if it doesn't return within
+// the timeout (a watcher failed to watch or the notification is lost).
+func waitTimeout(wait func(), timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out in %s", timeout)
+	}
+}
@@ -0,0 +1,28 @@
+package supervision
+
+import (
+	"fmt"
+	"testing"
+
+	"harness"
+)
+
+func TestPropagation(t *testing.T) {
+	for _, watch := range []string{watchMonitor, watchLink} {
+		for _, nodes := range []int{0, 3} {
+			t.Run(fmt.Sprintf("%s/%d", watch, nodes), func(t *testing.T) {
+				// the run is done once every watcher is notified
+				r, err := harness.RunOnce("propagation/"+watch, harness.Params{"watchers": 300, "nodes": nodes})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Ops != 300 {
+					t.Fatalf("expected 300 watchers notified, got %d", r.Ops)
+				}
+				if m, found := r.Metric("delivery rate"); found == false || m.Value <= 0 {
+					t.Fatalf("expected positive delivery rate, got %f", m.Value)
+				}
+			})
+		}
+	}
+}
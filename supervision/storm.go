@@ -0,0 +1,214 @@
+package supervision
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+func init() {
+	for _, s := range []struct {
+		name    string
+		typ     act.SupervisorType
+		crashes int
+		about   string
+	}{
+		{"one-for-one", act.SupervisorTypeOneForOne, 10_000, "only the crashed child is restarted"},
+		{"all-for-one", act.SupervisorTypeAllForOne, 100, "all the children are restarted"},
+		{"rest-for-one", act.SupervisorTypeRestForOne, 100, "the crashed child and the ones started after it are restarted"},
+		{"simple-one-for-one", act.SupervisorTypeSimpleOneForOne, 10_000, "children are started dynamically, only the crashed one is restarted"},
+	} {
+		typ := s.typ
+		harness.Register(harness.Scenario{
+			Name:        "supervision/" + s.name,
+			Description: "restart storm: the children of the supervisor crash at the rate, " + s.about,
+			Params:      stormParams(s.crashes),
+			Run: func(b *harness.B) error {
+				return runStorm(b, typ)
+			},
+		})
+	}
+}
+
+// stormParams returns the parameters of a restart storm scenario.
+func stormParams(crashes int) []harness.Param {
+	return []harness.Param{
+		{Name: "children", Usage: "number of children of the supervisor", Default: 10_000},
+		{Name: "crashes", Usage: "number of crashes (the children crash in turn)", Default: crashes},
+		{Name: "rate", Usage: "crashes per second, 0 crashes the next child as soon as it's running", Default: 0},
+		{Name: "intensity", Usage: "restart intensity of the supervisor: restarts allowed within the period", Default: math.MaxUint16},
+		{Name: "period", Usage: "restart period of the supervisor in seconds", Default: 1},
+		{Name: "timeout", Usage: "time a crashed child is given to be restarted, the run fails after it", Default: 10 * time.Second},
+	}
+}
+
+// runStorm starts the supervisor with the children and crashes them one by
+// one. The restarted child records the time since its crash (the restart
+// latency). Every crash counts as done once the crashed child is running
+// again, the siblings restarted along with it are counted as restarts too.
+func runStorm(b *harness.B, typ act.SupervisorType) error {
+	nc := b.Int("children")
+	crashes := b.Int("crashes")
+	rate := b.Int("rate")
+	intensity := b.Int("intensity")
+	period := b.Int("period")
+	timeout := b.Duration("timeout")
+	if nc < 1 {
+		return fmt.Errorf("number of children must be positive")
+	}
+	if crashes < 1 {
+		return fmt.Errorf("number of crashes must be positive")
+	}
+	if rate < 0 {
+		return fmt.Errorf("rate must not be negative")
+	}
+	if intensity < 1 || intensity > math.MaxUint16 {
+		return fmt.Errorf("intensity must be between 1 and %d", math.MaxUint16)
+	}
+	if period < 1 || period > math.MaxUint16 {
+		return fmt.Errorf("period must be between 1 and %d", math.MaxUint16)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	node, err := b.StartNode("supervision@localhost", gen.NodeOptions{})
+	if err != nil {
+		return err
+	}
+
+	rc := harness.NewRunContext()
+	t := newTracker(rc, nc)
+	config := supervisorConfig{
+		typ:       typ,
+		children:  nc,
+		intensity: uint16(intensity),
+		period:    uint16(period),
+		tracker:   t,
+	}
+	sup, err := node.Spawn(factory_supervisor, gen.ProcessOptions{}, config)
+	if err != nil {
+		return err
+	}
+	if typ == act.SupervisorTypeSimpleOneForOne {
+		if err := node.Send(sup, startChildren{n: nc}); err != nil {
+			return err
+		}
+	}
+	if err := waitStarted(t, int64(nc)); err != nil {
+		return err
+	}
+
+	runtime.GC()
+	start, err := node.Info()
+	if err != nil {
+		return err
+	}
+
+	if rate > 0 {
+		node.Log().Info("BENCHMARK: %d crashes of %d children at %d crashes/sec", crashes, nc, rate)
+	} else {
+		node.Log().Info("BENCHMARK: %d crashes of %d children", crashes, nc)
+	}
+	b.StartTimer()
+	begin := time.Now()
+	for i := 0; i < crashes; i++ {
+		if rate > 0 {
+			// open loop: the crash is due regardless of the restarts
+			if wait := time.Until(begin.Add(time.Duration(i) * time.Second / time.Duration(rate))); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+		if err := crashChild(node, t, i%nc, timeout); err != nil {
+			return err
+		}
+	}
+	if err := waitRestarts(t, timeout); err != nil {
+		return err
+	}
+	elapsed := b.StopTimer()
+
+	// the siblings may still be restarting
+	end, err := harness.WaitProcesses(node, start.ProcessesTotal, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if end, err = node.Info(); err != nil {
+		return err
+	}
+
+	restarts := t.started.Load() - int64(nc)
+	b.SetOps(crashes)
+	b.ReportLatency("restart latency", t.latency)
+	b.ReportMetric("crash rate", float64(crashes)/elapsed.Seconds(), "crash/sec")
+	b.ReportMetric("restart throughput", float64(restarts)/elapsed.Seconds(), "restart/sec")
+	b.ReportMetric("restarts per crash", float64(restarts)/float64(crashes), "restart")
+	b.ReportMetric("memory start", float64(start.MemoryAlloc)/1024.0, "KB")
+	b.ReportMetric("memory end", float64(end.MemoryAlloc)/1024.0, "KB")
+	b.ReportMetric("memory growth", (float64(end.MemoryAlloc)-float64(start.MemoryAlloc))/1024.0, "KB")
+	b.ReportMetric("processes leaked", float64(end.ProcessesTotal-start.ProcessesTotal), "proc")
+	return nil
+}
+
+// crashChild crashes the child as soon as it's running. It waits for the
+// child to signal its restart rather than polling, so the driver doesn't take
+// CPU from the supervisor.
+func crashChild(node gen.Node, t *tracker, i int, timeout time.Duration) error {
+	var deadline <-chan time.Time
+	for t.crash(node, i) == false {
+		if deadline == nil {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-t.running[i]:
+		case <-t.stopped:
+			return t.failed()
+		case <-deadline:
+			return fmt.Errorf("child %d is not restarted in %s", i, timeout)
+		}
+	}
+	return nil
+}
+
+// waitStarted waits until the children are started.
+func waitStarted(t *tracker, n int64) error {
+	deadline := time.Now().Add(time.Minute)
+	for t.started.Load() < n {
+		if err := t.failed(); err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d of %d children are started in 1m", t.started.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+// waitRestarts waits until all the crashed children are restarted. It fails
+// if the supervisor terminates or the children aren't restarted within the
+// timeout.
+func waitRestarts(t *tracker, timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		t.rc.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-t.stopped:
+		return t.failed()
+	case <-time.After(timeout):
+		return fmt.Errorf("crashed children are not restarted in %s", timeout)
+	}
+}
@@ -0,0 +1,70 @@
+package supervision
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"harness"
+)
+
+func TestStorm(t *testing.T) {
+	const children = 100
+	for _, s := range []struct {
+		name     string
+		crashes  int
+		restarts float64 // per crash
+	}{
+		{"supervision/one-for-one", 300, 1},
+		{"supervision/simple-one-for-one", 300, 1},
+		{"supervision/all-for-one", 20, children},
+		// every child crashes once, the child i restarts children-i of them
+		{"supervision/rest-for-one", children, (children + 1) / 2.0},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			r, err := harness.RunOnce(s.name, harness.Params{"children": children, "crashes": s.crashes})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Ops != s.crashes {
+				t.Fatalf("expected %d crashes, got %d", s.crashes, r.Ops)
+			}
+			if m, found := r.Metric("restarts per crash"); found == false || m.Value != s.restarts {
+				t.Fatalf("expected %.1f restarts per crash, got %.2f", s.restarts, m.Value)
+			}
+			if m, found := r.Metric("processes leaked"); found == false || m.Value != 0 {
+				t.Fatalf("expected no processes leaked, got %.0f", m.Value)
+			}
+			if m, _ := r.Metric("restart latency max"); m.Value <= 0 {
+				t.Fatalf("expected the restart latency to be recorded")
+			}
+		})
+	}
+}
+
+func TestStormRate(t *testing.T) {
+	// the last of 200 crashes is due in 199ms
+	r, err := harness.RunOnce("supervision/one-for-one", harness.Params{"children": 100, "crashes": 200, "rate": 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ops != 200 {
+		t.Fatalf("expected 200 crashes, got %d", r.Ops)
+	}
+	if r.Elapsed < 199*time.Millisecond {
+		t.Fatalf("expected the crashes on schedule within 199ms at least, got %s", r.Elapsed)
+	}
+}
+
+func TestStormIntensity(t *testing.T) {
+	_, err := harness.RunOnce("supervision/one-for-one", harness.Params{
+		"children":  100,
+		"crashes":   100,
+		"intensity": 5,
+		"period":    10,
+		"timeout":   time.Second,
+	})
+	if err == nil || strings.Contains(err.Error(), "supervisor terminated") == false {
+		t.Fatalf("expected the supervisor to terminate once the intensity is exceeded, got %v", err)
+	}
+}
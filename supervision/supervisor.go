@@ -0,0 +1,192 @@
+package supervision
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+// errCrash is the reason the worker terminates with when it's told to crash
+var errCrash = errors.New("crash")
+
+// crash makes the worker terminate abnormally
+type crash struct{}
+
+// startChildren makes the simple one-for-one supervisor start the children
+type startChildren struct {
+	n int
+}
+
+// tracker keeps the PIDs of the workers and the time they were crashed at, so
+// the restarted worker can tell how long the restart took. It's shared by all
+// the workers of the run (they are on the same node).
+type tracker struct {
+	rc      *harness.RunContext
+	pids    []atomic.Pointer[gen.PID]
+	crashed []atomic.Int64 // time the worker was crashed at (UnixNano), 0 if it's running
+	started atomic.Int64
+	running []chan struct{}       // signalled by the worker once it's (re)started
+	down    atomic.Pointer[error] // reason the supervisor terminated with
+	stopped chan struct{}         // closed once the supervisor terminated
+
+	sync.Mutex
+	latency *harness.Histogram
+}
+
+func newTracker(rc *harness.RunContext, children int) *tracker {
+	t := &tracker{
+		rc:      rc,
+		pids:    make([]atomic.Pointer[gen.PID], children),
+		crashed: make([]atomic.Int64, children),
+		running: make([]chan struct{}, children),
+		stopped: make(chan struct{}),
+		latency: harness.NewHistogram(),
+	}
+	for i := range t.running {
+		// a pending signal is enough, the waiter checks the state anyway
+		t.running[i] = make(chan struct{}, 1)
+	}
+	return t
+}
+
+// start is called by the worker once it's (re)started.
+func (t *tracker) start(i int, pid gen.PID) {
+	t.pids[i].Store(&pid)
+	t.started.Add(1)
+
+	crashed := t.crashed[i].Swap(0)
+	select {
+	case t.running[i] <- struct{}{}:
+	default:
+	}
+	if crashed == 0 {
+		// the first start or a sibling restarted along with the crashed one
+		return
+	}
+	t.Lock()
+	t.latency.Record(time.Duration(time.Now().UnixNano() - crashed))
+	t.Unlock()
+	t.rc.Done()
+}
+
+// crash makes the worker crash. It returns false if the worker isn't running
+// (it's being restarted).
+func (t *tracker) crash(node gen.Node, i int) bool {
+	pid := t.pids[i].Load()
+	if pid == nil || t.crashed[i].Load() != 0 {
+		return false
+	}
+	t.rc.Add(1)
+	now := time.Now().UnixNano()
+	t.crashed[i].Store(now)
+	if err := node.Send(*pid, crash{}); err != nil {
+		// it has just terminated along with a sibling. If it has been
+		// restarted already, the restart is counted by start.
+		if t.crashed[i].CompareAndSwap(now, 0) {
+			t.rc.Done()
+		}
+		return false
+	}
+	return true
+}
+
+// failed returns the reason the supervisor terminated with, nil if it's alive.
+func (t *tracker) failed() error {
+	if reason := t.down.Load(); reason != nil {
+		return fmt.Errorf("supervisor terminated: %w", *reason)
+	}
+	return nil
+}
+
+// supervisorConfig is passed to the supervisor in the spawn args
+type supervisorConfig struct {
+	typ       act.SupervisorType
+	children  int
+	intensity uint16
+	period    uint16
+	tracker   *tracker
+}
+
+func factory_supervisor() gen.ProcessBehavior {
+	return &supervisor{}
+}
+
+type supervisor struct {
+	act.Supervisor
+
+	tracker *tracker
+}
+
+func (s *supervisor) Init(args ...any) (act.SupervisorSpec, error) {
+	config := args[0].(supervisorConfig)
+	s.tracker = config.tracker
+
+	spec := act.SupervisorSpec{
+		Type: config.typ,
+		Restart: act.SupervisorRestart{
+			Strategy:  act.SupervisorStrategyTransient,
+			Intensity: config.intensity,
+			Period:    config.period,
+		},
+	}
+
+	if config.typ == act.SupervisorTypeSimpleOneForOne {
+		// the children are started by StartChild with their index
+		spec.Children = []act.SupervisorChildSpec{
+			{Name: "worker", Factory: factory_worker},
+		}
+		return spec, nil
+	}
+
+	for i := 0; i < config.children; i++ {
+		spec.Children = append(spec.Children, act.SupervisorChildSpec{
+			Name:    gen.Atom(fmt.Sprintf("worker%d", i)),
+			Factory: factory_worker,
+			Args:    []any{config.tracker, i},
+		})
+	}
+	return spec, nil
+}
+
+func (s *supervisor) HandleMessage(from gen.PID, message any) error {
+	if m, ok := message.(startChildren); ok {
+		for i := 0; i < m.n; i++ {
+			if err := s.StartChild("worker", s.tracker, i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *supervisor) Terminate(reason error) {
+	// the restart intensity is exceeded or a child failed to start
+	s.tracker.down.Store(&reason)
+	close(s.tracker.stopped)
+}
+
+func factory_worker() gen.ProcessBehavior {
+	return &worker{}
+}
+
+type worker struct {
+	act.Actor
+}
+
+func (w *worker) Init(args ...any) error {
+	args[0].(*tracker).start(args[1].(int), w.PID())
+	return nil
+}
+
+func (w *worker) HandleMessage(from gen.PID, message any) error {
+	if _, ok := message.(crash); ok {
+		return errCrash
+	}
+	return nil
+}
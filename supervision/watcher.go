@@ -0,0 +1,77 @@
+package supervision
+
+import (
+	"ergo.services/ergo/act"
+	"ergo.services/ergo/gen"
+	"harness"
+)
+
+func factory_watched() gen.ProcessBehavior {
+	return &watched{}
+}
+
+// watched is the process linked to or monitored by the watchers. It does
+// nothing but terminates once it receives the exit signal.
+type watched struct {
+	act.Actor
+}
+
+func factory_watcher() gen.ProcessBehavior {
+	return &watcher{}
+}
+
+// watcher links to or monitors the watched process and waits for the exit
+// signal or the down message.
+type watcher struct {
+	act.Actor
+
+	target gen.PID
+	watch  string
+	rc     *harness.RunContext
+}
+
+type doWatch struct{}
+
+func (w *watcher) Init(args ...any) error {
+	w.target = args[0].(gen.PID)
+	w.watch = args[1].(string)
+	w.rc = args[2].(*harness.RunContext)
+	if w.watch == watchLink {
+		// receive the exit signal as a message instead of terminating
+		w.SetTrapExit(true)
+	}
+	w.Send(w.PID(), doWatch{})
+	return nil
+}
+
+func (w *watcher) HandleMessage(from gen.PID, message any) error {
+	switch m := message.(type) {
+	case doWatch:
+		var err error
+		if w.watch == watchLink {
+			err = w.LinkPID(w.target)
+		} else {
+			err = w.MonitorPID(w.target)
+		}
+		if err != nil {
+			if err == gen.ErrTimeout {
+				// the request to the node of the watched process timed out, try again
+				w.Send(w.PID(), doWatch{})
+				return nil
+			}
+			return err
+		}
+		w.rc.Ready()
+
+	case gen.MessageDownPID:
+		if m.PID == w.target {
+			w.rc.Done()
+		}
+
+	case gen.MessageExitPID:
+		if m.PID == w.target {
+			w.rc.Done()
+		}
+	}
+	return nil
+}